@@ -2,6 +2,13 @@ provider "rackcorp" {
   api_uuid    = "the-uuid-from-tf"
   api_secret  = "the-secret-from-tf"
   customer_id = "001122"
+
+  // max_retries       = 3
+  // retry_max_backoff = "30s"
+
+  // max_concurrent_requests = 4
+  // requests_per_second     = 2
+  // max_parallel_orders     = 5
 }
 
 resource "rackcorp_server" "example" {
@@ -44,6 +51,10 @@ resource "rackcorp_server" "example" {
   ]
   */
 
+  // Set instead of firewall_policies when the rules of the server are managed
+  // by rackcorp_firewall_policy or rackcorp_firewall_ruleset_attachment.
+  // external_firewall_policies = true
+
   // data_center_id = 19
 
   // name = "the-hostname-from-tf"
@@ -54,6 +65,17 @@ resource "rackcorp_server" "example" {
 
   // host_group_id = 5
 
+  // power_state = "off" // or on
+
+  // cancel_on_create_failure = true
+
+  // deletion_protection = true
+
+  // wait_for_cancellation = false
+
+  // ssh_authorized_keys = ["${file("~/.ssh/id_ed25519.pub")}"]
+  // ssh_username        = "deploy"
+
   /*
   storage = [
     {
@@ -62,4 +84,52 @@ resource "rackcorp_server" "example" {
     },
   ]
   */
+
+  /*
+  timeouts {
+    create = "90m"
+    update = "30m"
+    delete = "30m"
+  }
+  */
+}
+
+/*
+// Rules of a device can also be managed one at a time, for example by another
+// module. The server must set external_firewall_policies, otherwise it plans
+// to remove the rules managed this way.
+resource "rackcorp_firewall_policy" "ssh" {
+  device_id       = "${rackcorp_server.example.device_id}"
+  direction       = "INBOUND"
+  policy          = "ALLOW"
+  protocol        = "TCP"
+  port_to         = "22"
+  ip_address_from = "203.0.113.5"
+  comment         = "SSH"
+  order           = 10
+}
+*/
+
+/*
+// A ruleset defines rules once and an attachment applies them to many
+// devices. Changing the ruleset updates every attached device.
+resource "rackcorp_firewall_ruleset" "web" {
+  name = "web"
+
+  firewall_policies = [
+    {
+      direction = "INBOUND"
+      policy    = "ALLOW"
+      protocol  = "TCP"
+      port_to   = "443"
+      comment   = "HTTPS"
+      order     = 10
+    },
+  ]
+}
+
+resource "rackcorp_firewall_ruleset_attachment" "web" {
+  ruleset_policies = "${rackcorp_firewall_ruleset.web.policies_json}"
+  device_ids       = ["${rackcorp_server.example.device_id}"]
 }
+*/
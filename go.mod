@@ -24,5 +24,6 @@ require (
 	github.com/pkg/errors v0.9.1
 	github.com/section-io/rackcorp-sdk-go/v2 v2.1.0
 	github.com/zclconf/go-cty v1.4.0 // indirect
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616
 )
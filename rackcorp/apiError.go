@@ -0,0 +1,97 @@
+package rackcorp
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// Classes of Rackcorp API error, matched with errors.Is after
+// classifyAPIError.
+var (
+	errAPINotFound     = errors.New("not found")
+	errAPIAccessDenied = errors.New("access denied")
+	errAPIFault        = errors.New("fault")
+	errAPIValidation   = errors.New("validation failed")
+	errAPIRateLimited  = errors.New("rate limited")
+)
+
+type classifiedAPIError struct {
+	class error
+	hint  string
+	err   *api.ApiError
+}
+
+func (e *classifiedAPIError) Error() string {
+	if e.hint == "" {
+		return e.err.Error()
+	}
+	return e.err.Error() + " (" + e.hint + ")"
+}
+
+func (e *classifiedAPIError) Is(target error) bool {
+	return target == e.class
+}
+
+func (e *classifiedAPIError) Unwrap() error {
+	return e.err
+}
+
+// classifyAPIError returns err annotated with its class when it is an
+// api.ApiError that can be classified, otherwise err is returned unchanged.
+//
+// api.ApiError only holds the response code when the response has no message,
+// so the message text is matched as well. Not found removes resources from
+// state, so it is only returned for the exact messages Rackcorp sends for a
+// missing device, order or contract.
+func classifyAPIError(err error) error {
+	var apiErr *api.ApiError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	if _, ok := err.(*classifiedAPIError); ok {
+		return err
+	}
+
+	message := strings.ToLower(apiErr.Message)
+	switch {
+	case apiErr.Message == rackcorpAPIResponseCodeAccessDenied,
+		strings.Contains(message, "access denied"),
+		strings.Contains(message, "permission"):
+		return &classifiedAPIError{
+			class: errAPIAccessDenied,
+			hint:  "check the api_uuid, api_secret and customer_id provider settings and the permissions of the API key",
+			err:   apiErr,
+		}
+	case strings.Contains(message, "rate limit"),
+		strings.Contains(message, "too many requests"):
+		return &classifiedAPIError{
+			class: errAPIRateLimited,
+			hint:  "the Rackcorp API is throttling requests, retry later or lower the parallelism",
+			err:   apiErr,
+		}
+	case apiErr.Message == rackcorpAPIMessageDeviceNotFound,
+		apiErr.Message == rackcorpAPIMessageOrderNotFound,
+		apiErr.Message == rackcorpAPIMessageContractNotFound:
+		return &classifiedAPIError{
+			class: errAPINotFound,
+			err:   apiErr,
+		}
+	case strings.Contains(message, "invalid"),
+		strings.Contains(message, "required"),
+		strings.Contains(message, "missing"):
+		return &classifiedAPIError{
+			class: errAPIValidation,
+			err:   apiErr,
+		}
+	case apiErr.Message == rackcorpAPIResponseCodeFault:
+		return &classifiedAPIError{
+			class: errAPIFault,
+			err:   apiErr,
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,68 @@
+package rackcorp
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+func TestClassifyAPIError(t *testing.T) {
+	classes := []error{
+		errAPINotFound,
+		errAPIAccessDenied,
+		errAPIFault,
+		errAPIValidation,
+		errAPIRateLimited,
+	}
+
+	testCases := []struct {
+		name  string
+		err   error
+		class error
+	}{
+		{"device not found", &api.ApiError{Message: "Could not find device"}, errAPINotFound},
+		{"order not found", &api.ApiError{Message: "Could not find order"}, errAPINotFound},
+		{"contract not found", &api.ApiError{Message: "Could not find contract"}, errAPINotFound},
+		{"wrapped device not found", errors.Wrap(&api.ApiError{Message: "Could not find device"}, "context"), errAPINotFound},
+		{"customer not found", &api.ApiError{Message: "customer not found"}, nil},
+		{"command not found", &api.ApiError{Message: "sh: virsh: command not found"}, nil},
+		{"could not find other object", &api.ApiError{Message: "Could not find device template"}, nil},
+		{"device not found with other case", &api.ApiError{Message: "could not find device"}, nil},
+		{"access denied code", &api.ApiError{Message: "ACCESS_DENIED"}, errAPIAccessDenied},
+		{"access denied message", &api.ApiError{Message: "Access denied to device"}, errAPIAccessDenied},
+		{"permission message", &api.ApiError{Message: "No permission for customer"}, errAPIAccessDenied},
+		{"rate limit message", &api.ApiError{Message: "Rate limit exceeded"}, errAPIRateLimited},
+		{"too many requests message", &api.ApiError{Message: "Too Many Requests"}, errAPIRateLimited},
+		{"invalid message", &api.ApiError{Message: "Invalid productCode"}, errAPIValidation},
+		{"required message", &api.ApiError{Message: "customerId is required"}, errAPIValidation},
+		{"fault code", &api.ApiError{Message: "FAULT"}, errAPIFault},
+		{"fault with debug text", &api.ApiError{Message: "database unavailable"}, nil},
+		{"not an api error", errors.New("Could not find device"), nil},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifyAPIError(tc.err)
+			for _, class := range classes {
+				if got, want := errors.Is(err, class), class == tc.class; got != want {
+					t.Errorf("errors.Is(classifyAPIError(%q), %q) = %t, want %t", tc.err, class, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyAPIErrorKeepsMessage(t *testing.T) {
+	err := classifyAPIError(&api.ApiError{Message: "ACCESS_DENIED"})
+	if got := err.Error(); !strings.HasPrefix(got, "ACCESS_DENIED (") {
+		t.Errorf("classifyAPIError(...).Error() = %q, want the message followed by a hint", got)
+	}
+
+	if classifyAPIError(err) != err {
+		t.Error("classifyAPIError of a classified error should return it unchanged")
+	}
+}
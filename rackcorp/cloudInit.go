@@ -0,0 +1,129 @@
+package rackcorp
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+const cloudInitBoundary = "==RACKCORP-TERRAFORM-PROVIDER=="
+
+func validateSSHAuthorizedKey(v interface{}, k string) (ws []string, es []error) {
+	value, ok := v.(string)
+	if !ok {
+		es = append(es, errors.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value)); err != nil {
+		es = append(es, errors.Wrapf(err, "%s is not a valid SSH public key", k))
+	}
+	return
+}
+
+// cloudInitUserData returns the configured user data with the SSH authorized
+// keys added. When user data is also configured the keys are sent as a second
+// cloud-config part that cloud-init merges into the first.
+func cloudInitUserData(d *schema.ResourceData) (string, error) {
+	userData := d.Get("user_data").(string)
+
+	var keys []string
+	for _, key := range d.Get("ssh_authorized_keys").([]interface{}) {
+		keys = append(keys, key.(string))
+	}
+
+	if len(keys) == 0 {
+		return userData, nil
+	}
+
+	keysConfig := sshAuthorizedKeysCloudConfig(d.Get("ssh_username").(string), keys)
+	if userData == "" {
+		return keysConfig, nil
+	}
+
+	if strings.HasPrefix(userData, "Content-Type: multipart/") {
+		return "", errors.New("ssh_authorized_keys cannot be added to multipart user_data")
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("Content-Type: multipart/mixed; boundary=\"" + cloudInitBoundary + "\"\n")
+	buffer.WriteString("MIME-Version: 1.0\n\n")
+
+	writer := multipart.NewWriter(&buffer)
+	if err := writer.SetBoundary(cloudInitBoundary); err != nil {
+		return "", err
+	}
+
+	for _, part := range []string{userData, keysConfig} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", cloudInitContentType(part)+"; charset=\"utf-8\"")
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		if _, err := partWriter.Write([]byte(part)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+func sshAuthorizedKeysCloudConfig(username string, keys []string) string {
+	var lines []string
+	lines = append(lines, "#cloud-config")
+
+	indent := ""
+	if username != "" {
+		lines = append(lines, "users:", "  - default", "  - name: "+yamlQuote(username))
+		indent = "    "
+	}
+
+	lines = append(lines, indent+"ssh_authorized_keys:")
+	for _, key := range keys {
+		lines = append(lines, indent+"  - "+yamlQuote(strings.TrimSpace(key)))
+	}
+
+	// Append to lists from earlier parts rather than replacing them.
+	lines = append(lines,
+		"merge_how:",
+		"  - name: list",
+		"    settings: [append]",
+		"  - name: dict",
+		"    settings: [no_replace, recurse_list]",
+	)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// yamlQuote returns s as a double quoted scalar. The escapes used by Go are a
+// subset of those supported by YAML.
+func yamlQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+// cloudInitContentType maps the user data prefixes recognised by cloud-init to
+// the equivalent MIME part types.
+func cloudInitContentType(userData string) string {
+	switch {
+	case strings.HasPrefix(userData, "#cloud-config"):
+		return "text/cloud-config"
+	case strings.HasPrefix(userData, "#cloud-boothook"):
+		return "text/cloud-boothook"
+	case strings.HasPrefix(userData, "#include"):
+		return "text/x-include-url"
+	case strings.HasPrefix(userData, "#!"):
+		return "text/x-shellscript"
+	}
+	return "text/plain"
+}
@@ -0,0 +1,191 @@
+package rackcorp
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// fakeClient is an in-memory api.Client for tests. Firewall updates apply the
+// same DELETED and add semantics the provider relies on.
+type fakeClient struct {
+	mutex sync.Mutex
+
+	orders    map[string]*api.Order
+	contracts map[string]*api.OrderContract
+	devices   map[int]*api.Device
+
+	transactions    []api.Transaction
+	firewallUpdates map[int]int
+	// firewallErrors fails the next firewall update of a device.
+	firewallErrors map[int]error
+	nextPolicyID   int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		orders:          map[string]*api.Order{},
+		contracts:       map[string]*api.OrderContract{},
+		devices:         map[int]*api.Device{},
+		firewallUpdates: map[int]int{},
+		firewallErrors:  map[int]error{},
+		nextPolicyID:    1000,
+	}
+}
+
+func setFakePowerStatus(device *api.Device, powerStatus string) {
+	device.Extra = map[string]interface{}{
+		"SYS_POWERSWITCH": "ONLINE",
+		"SYS_POWERSTATUS": powerStatus,
+	}
+	if powerStatus == "OFFLINE" {
+		device.Extra["SYS_POWERSWITCH"] = "OFFLINE"
+	}
+}
+
+func (c *fakeClient) addDevice(deviceID int, powerStatus string, policies ...api.FirewallPolicy) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device := &api.Device{DeviceId: deviceID}
+	setFakePowerStatus(device, powerStatus)
+	for _, policy := range policies {
+		c.nextPolicyID++
+		policy.ID = c.nextPolicyID
+		device.FirewallPolicies = append(device.FirewallPolicies, policy)
+	}
+	c.devices[deviceID] = device
+}
+
+func (c *fakeClient) transactionTypes(objectID string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var result []string
+	for _, transaction := range c.transactions {
+		if transaction.ObjectId == objectID {
+			result = append(result, transaction.Type)
+		}
+	}
+	return result
+}
+
+func (c *fakeClient) OrderConfirm(orderID string) (*api.ConfirmedOrder, error) {
+	return nil, errors.New("OrderConfirm is not supported by fakeClient")
+}
+
+func (c *fakeClient) OrderCreate(productCode string, customerID string, productDetails api.ProductDetails) (*api.CreatedOrder, error) {
+	return nil, errors.New("OrderCreate is not supported by fakeClient")
+}
+
+func (c *fakeClient) OrderGet(orderID string) (*api.Order, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if order, ok := c.orders[orderID]; ok {
+		result := *order
+		return &result, nil
+	}
+	return nil, &api.ApiError{Message: rackcorpAPIMessageOrderNotFound}
+}
+
+func (c *fakeClient) OrderContractGet(contractID string) (*api.OrderContract, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if contract, ok := c.contracts[contractID]; ok {
+		result := *contract
+		return &result, nil
+	}
+	return nil, &api.ApiError{Message: rackcorpAPIMessageContractNotFound}
+}
+
+func (c *fakeClient) DeviceGet(deviceID int) (*api.Device, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, ok := c.devices[deviceID]
+	if !ok {
+		return nil, &api.ApiError{Message: rackcorpAPIMessageDeviceNotFound}
+	}
+	result := *device
+	result.FirewallPolicies = append([]api.FirewallPolicy{}, device.FirewallPolicies...)
+	return &result, nil
+}
+
+func (c *fakeClient) DeviceUpdateFirewall(deviceID int, policies []api.FirewallPolicy) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	device, ok := c.devices[deviceID]
+	if !ok {
+		return &api.ApiError{Message: rackcorpAPIMessageDeviceNotFound}
+	}
+	if err, ok := c.firewallErrors[deviceID]; ok {
+		delete(c.firewallErrors, deviceID)
+		return err
+	}
+	c.firewallUpdates[deviceID]++
+
+	for _, policy := range policies {
+		if policy.Policy == "DELETED" {
+			for index, existing := range device.FirewallPolicies {
+				if existing.ID == policy.ID {
+					device.FirewallPolicies = append(device.FirewallPolicies[:index], device.FirewallPolicies[index+1:]...)
+					break
+				}
+			}
+			continue
+		}
+
+		if policy.ID != 0 {
+			return errors.Errorf("fakeClient only adds new firewall policies, got id %d", policy.ID)
+		}
+		c.nextPolicyID++
+		policy.ID = c.nextPolicyID
+		device.FirewallPolicies = append(device.FirewallPolicies, policy)
+	}
+	return nil
+}
+
+func (c *fakeClient) TransactionCreate(transactionType string, objectType string, objectID string, confirm bool) (*api.Transaction, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	transaction := api.Transaction{
+		TransactionId: strconv.Itoa(len(c.transactions) + 1),
+		ObjectType:    objectType,
+		ObjectId:      objectID,
+		Type:          transactionType,
+		Status:        api.TransactionStatusCompleted,
+	}
+	c.transactions = append(c.transactions, transaction)
+
+	deviceID, _ := strconv.Atoi(objectID)
+	if device, ok := c.devices[deviceID]; ok {
+		switch transactionType {
+		case api.TransactionTypeStartup:
+			setFakePowerStatus(device, "ONLINE")
+		case api.TransactionTypeShutdown:
+			setFakePowerStatus(device, "OFFLINE")
+		}
+	}
+	return &transaction, nil
+}
+
+func (c *fakeClient) TransactionDeviceStartup(deviceID string, data api.TransactionStartupData) (*api.Transaction, error) {
+	return c.TransactionCreate(api.TransactionTypeStartup, api.TransactionObjectTypeDevice, deviceID, true)
+}
+
+func (c *fakeClient) TransactionGet(transactionID string) (*api.Transaction, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, transaction := range c.transactions {
+		if transaction.TransactionId == transactionID {
+			result := transaction
+			return &result, nil
+		}
+	}
+	return nil, &api.ApiError{Message: "Could not find transaction"}
+}
+
+// TransactionGetAll reports no pending transactions, the fake completes every
+// transaction straight away.
+func (c *fakeClient) TransactionGetAll(filter api.TransactionFilter) ([]api.Transaction, int, error) {
+	return nil, 0, nil
+}
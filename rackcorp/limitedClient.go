@@ -0,0 +1,111 @@
+package rackcorp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// limitedClient limits the number of Rackcorp API requests in flight and
+// the rate at which they are sent. A single limitedClient is shared by every
+// resource of a provider so that the limits apply to the whole apply.
+type limitedClient struct {
+	client   api.Client
+	requests chan struct{}
+	interval time.Duration
+
+	mutex sync.Mutex
+	next  time.Time
+}
+
+// newLimitedClient returns a client allowing at most maxConcurrent requests
+// at once and requestsPerSecond requests a second. Zero disables a limit.
+func newLimitedClient(client api.Client, maxConcurrent int, requestsPerSecond float64) api.Client {
+	result := &limitedClient{
+		client: client,
+	}
+	if maxConcurrent > 0 {
+		result.requests = make(chan struct{}, maxConcurrent)
+	}
+	if requestsPerSecond > 0 {
+		result.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return result
+}
+
+// acquire blocks until a request may be sent and returns the function that
+// releases it.
+func (c *limitedClient) acquire() func() {
+	if c.interval > 0 {
+		c.mutex.Lock()
+		now := time.Now()
+		if c.next.Before(now) {
+			c.next = now
+		}
+		wait := c.next.Sub(now)
+		c.next = c.next.Add(c.interval)
+		c.mutex.Unlock()
+
+		time.Sleep(wait)
+	}
+
+	if c.requests == nil {
+		return func() {}
+	}
+
+	c.requests <- struct{}{}
+	return func() {
+		<-c.requests
+	}
+}
+
+func (c *limitedClient) OrderConfirm(orderID string) (*api.ConfirmedOrder, error) {
+	defer c.acquire()()
+	return c.client.OrderConfirm(orderID)
+}
+
+func (c *limitedClient) OrderCreate(productCode string, customerID string, productDetails api.ProductDetails) (*api.CreatedOrder, error) {
+	defer c.acquire()()
+	return c.client.OrderCreate(productCode, customerID, productDetails)
+}
+
+func (c *limitedClient) OrderGet(orderID string) (*api.Order, error) {
+	defer c.acquire()()
+	return c.client.OrderGet(orderID)
+}
+
+func (c *limitedClient) OrderContractGet(contractID string) (*api.OrderContract, error) {
+	defer c.acquire()()
+	return c.client.OrderContractGet(contractID)
+}
+
+func (c *limitedClient) DeviceGet(deviceID int) (*api.Device, error) {
+	defer c.acquire()()
+	return c.client.DeviceGet(deviceID)
+}
+
+func (c *limitedClient) DeviceUpdateFirewall(deviceID int, policies []api.FirewallPolicy) error {
+	defer c.acquire()()
+	return c.client.DeviceUpdateFirewall(deviceID, policies)
+}
+
+func (c *limitedClient) TransactionCreate(transactionType string, objectType string, objectID string, confirm bool) (*api.Transaction, error) {
+	defer c.acquire()()
+	return c.client.TransactionCreate(transactionType, objectType, objectID, confirm)
+}
+
+func (c *limitedClient) TransactionDeviceStartup(deviceID string, data api.TransactionStartupData) (*api.Transaction, error) {
+	defer c.acquire()()
+	return c.client.TransactionDeviceStartup(deviceID, data)
+}
+
+func (c *limitedClient) TransactionGet(transactionID string) (*api.Transaction, error) {
+	defer c.acquire()()
+	return c.client.TransactionGet(transactionID)
+}
+
+func (c *limitedClient) TransactionGetAll(filter api.TransactionFilter) ([]api.Transaction, int, error) {
+	defer c.acquire()()
+	return c.client.TransactionGetAll(filter)
+}
@@ -1,8 +1,12 @@
 package rackcorp
 
 import (
+	"time"
+
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/pkg/errors"
 	"github.com/section-io/rackcorp-sdk-go/v2"
 )
 
@@ -28,10 +32,58 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("RACKCORP_CUSTOMER_ID", nil),
 				Description: "Your Rackcorp Customer ID.",
 			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The number of times a failed Rackcorp API request is retried.",
+			},
+			"retry_max_backoff": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "30s",
+				ValidateFunc: validateDuration,
+				Description:  "The longest time to wait between retries of a Rackcorp API request.",
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The most Rackcorp API requests in flight at once, 0 for no limit.",
+			},
+			"requests_per_second": {
+				Type:        schema.TypeFloat,
+				Optional:    true,
+				Default:     0,
+				Description: "The most Rackcorp API requests sent each second, 0 for no limit.",
+			},
+			"max_parallel_orders": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The most servers being ordered and provisioned at once, 0 for no limit.",
+			},
 		},
 
+		// TODO add rackcorp_server_volume to attach, grow and detach data disks
+		// on an existing device once the api package can change the storage of
+		// a contract. Storage can only be set by OrderCreate today.
+		//
+		// TODO add rackcorp_server_nic to add and remove network interfaces on an
+		// existing device and expose their addresses, once the api package can
+		// change the NICs of a contract and api.Device decodes ports and ips.
+		//
+		// TODO add rackcorp_ip_address to allocate an address from the customer
+		// pool and move it between devices, refreshing the configuration of both,
+		// once the api package has calls to allocate, assign and release ips.
 		ResourcesMap: map[string]*schema.Resource{
-			"rackcorp_server": resourceRackcorpServer(),
+			"rackcorp_firewall_policy":             resourceRackcorpFirewallPolicy(),
+			"rackcorp_firewall_ruleset":            resourceRackcorpFirewallRuleset(),
+			"rackcorp_firewall_ruleset_attachment": resourceRackcorpFirewallRulesetAttachment(),
+			"rackcorp_server":                      resourceRackcorpServer(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -44,15 +96,64 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		return nil, err
 	}
 
+	maxBackoff, err := time.ParseDuration(d.Get("retry_max_backoff").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	requestsPerSecond := d.Get("requests_per_second").(float64)
+	if requestsPerSecond < 0 {
+		return nil, errors.New("requests_per_second must not be negative")
+	}
+
+	client = newLimitedClient(client, d.Get("max_concurrent_requests").(int), requestsPerSecond)
+
 	config := providerConfig{
-		Client:     client,
+		Client:     newRetryClient(client, d.Get("max_retries").(int), maxBackoff),
 		CustomerID: d.Get("customer_id").(string),
 	}
 
+	if maxParallelOrders := d.Get("max_parallel_orders").(int); maxParallelOrders > 0 {
+		config.OrderSlots = make(chan struct{}, maxParallelOrders)
+	}
+
 	return config, nil
 }
 
+func validateDuration(v interface{}, k string) (ws []string, es []error) {
+	value, ok := v.(string)
+	if !ok {
+		es = append(es, errors.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		es = append(es, errors.Errorf("%s is not a valid duration: %s", k, err))
+		return
+	}
+
+	if duration <= 0 {
+		es = append(es, errors.Errorf("%s must be a positive duration", k))
+	}
+	return
+}
+
 type providerConfig struct {
 	Client     api.Client
 	CustomerID string
+	OrderSlots chan struct{}
+}
+
+// acquireOrderSlot blocks until another order may be placed and returns the
+// function that releases the slot.
+func (c providerConfig) acquireOrderSlot() func() {
+	if c.OrderSlots == nil {
+		return func() {}
+	}
+
+	c.OrderSlots <- struct{}{}
+	return func() {
+		<-c.OrderSlots
+	}
 }
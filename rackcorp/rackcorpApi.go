@@ -6,6 +6,10 @@ const (
 	rackcorpAPIResponseCodeAccessDenied = "ACCESS_DENIED"
 	rackcorpAPIResponseCodeFault        = "FAULT"
 
+	rackcorpAPIMessageDeviceNotFound   = "Could not find device"
+	rackcorpAPIMessageOrderNotFound    = "Could not find order"
+	rackcorpAPIMessageContractNotFound = "Could not find contract"
+
 	rackcorpAPIOrderStatusPending  = "PENDING"
 	rackcorpAPIOrderStatusAccepted = "ACCEPTED"
 
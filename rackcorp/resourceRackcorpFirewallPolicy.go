@@ -0,0 +1,304 @@
+package rackcorp
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// resourceRackcorpFirewallPolicy manages a single firewall rule of a device
+// so that rules can be owned by a different module than the server.
+//
+// The firewall_policies attribute of rackcorp_server reads every rule of the
+// device and would plan to delete the rules managed here, so the server must
+// set external_firewall_policies instead.
+func resourceRackcorpFirewallPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRackcorpFirewallPolicyCreate,
+		Delete: resourceRackcorpFirewallPolicyDelete,
+		Read:   resourceRackcorpFirewallPolicyRead,
+		Update: resourceRackcorpFirewallPolicyUpdate,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"device_id": {
+				Type:     schema.TypeInt,
+				Required: true,
+				ForceNew: true,
+			},
+			"policy_id": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"direction": {
+				Type:     schema.TypeString,
+				Required: true,
+				ValidateFunc: validation.StringInSlice(
+					api.FirewallPolicyDirections,
+					false,
+				),
+			},
+			"policy": {
+				Type:     schema.TypeString,
+				Required: true,
+				ValidateFunc: validation.StringInSlice(
+					api.FirewallPolicyTypes,
+					false,
+				),
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"ip_address_from": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"ip_address_to": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"port_from": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"port_to": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"protocol": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"order": {
+				Type:     schema.TypeInt,
+				Required: true,
+			},
+		},
+	}
+}
+
+func firewallPolicyID(deviceID int, policyID int) string {
+	return fmt.Sprintf("%d:%d", deviceID, policyID)
+}
+
+func parseFirewallPolicyID(id string) (int, int, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("Rackcorp firewall policy id '%s' is not of the form <device_id>:<policy_id>.", id)
+	}
+
+	deviceID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Invalid device id in Rackcorp firewall policy id '%s'.", id)
+	}
+
+	policyID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Invalid policy id in Rackcorp firewall policy id '%s'.", id)
+	}
+
+	return deviceID, policyID, nil
+}
+
+func translateStandaloneFirewallPolicy(d *schema.ResourceData) api.FirewallPolicy {
+	return convertStandaloneFirewallPolicy(d.Get)
+}
+
+// translateOldStandaloneFirewallPolicy returns the policy as it was before
+// the planned changes.
+func translateOldStandaloneFirewallPolicy(d *schema.ResourceData) api.FirewallPolicy {
+	return convertStandaloneFirewallPolicy(func(key string) interface{} {
+		old, _ := d.GetChange(key)
+		return old
+	})
+}
+
+func convertStandaloneFirewallPolicy(get func(string) interface{}) api.FirewallPolicy {
+	return api.FirewallPolicy{
+		ID:            get("policy_id").(int),
+		Direction:     get("direction").(string),
+		Policy:        get("policy").(string),
+		Order:         get("order").(int),
+		Comment:       get("comment").(string),
+		IpAddressFrom: get("ip_address_from").(string),
+		IpAddressTo:   get("ip_address_to").(string),
+		PortFrom:      get("port_from").(string),
+		PortTo:        get("port_to").(string),
+		Protocol:      get("protocol").(string),
+	}
+}
+
+// updateDeviceFirewall sends policy changes for a device and waits for the
+// refreshConfig transaction that applies them.
+func updateDeviceFirewall(deviceID int, policies []api.FirewallPolicy, config providerConfig, timeout time.Duration) error {
+	err := config.Client.DeviceUpdateFirewall(deviceID, policies)
+	if err != nil {
+		return errors.Wrapf(classifyAPIError(err), "Failed to update firewall policies for device id '%d'.", deviceID)
+	}
+
+	return refreshDeviceFirewall(deviceID, config, timeout)
+}
+
+func getDeviceFirewallPolicies(deviceID int, config providerConfig) ([]api.FirewallPolicy, error) {
+	device, err := config.Client.DeviceGet(deviceID)
+	if err != nil {
+		return nil, errors.Wrapf(classifyAPIError(err), "Could not get Rackcorp device with id '%d'.", deviceID)
+	}
+	return device.FirewallPolicies, nil
+}
+
+// addStandaloneFirewallPolicy adds the policy of the resource to the device,
+// together with any removed policies marked DELETED, and records the id
+// Rackcorp gives the new policy.
+func addStandaloneFirewallPolicy(d *schema.ResourceData, removed []api.FirewallPolicy, config providerConfig, timeout time.Duration) error {
+	deviceID := d.Get("device_id").(int)
+	policy := translateStandaloneFirewallPolicy(d)
+	policy.ID = 0
+
+	existing, err := getDeviceFirewallPolicies(deviceID, config)
+	if err != nil {
+		return err
+	}
+
+	err = config.Client.DeviceUpdateFirewall(deviceID, append(removed, policy))
+	if err != nil {
+		return errors.Wrapf(classifyAPIError(err), "Failed to update firewall policies for device id '%d'.", deviceID)
+	}
+
+	// The update request does not return the id of the new policy, so find
+	// the matching policy that was not on the device before. The id is set
+	// before waiting on the refresh so that a failed refresh still leaves the
+	// policy in state.
+	existingIDs := map[int]bool{}
+	for _, p := range existing {
+		existingIDs[p.ID] = true
+	}
+
+	current, err := getDeviceFirewallPolicies(deviceID, config)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, p := range current {
+		if !existingIDs[p.ID] && isFirewallSame(p, policy) {
+			d.SetId(firewallPolicyID(deviceID, p.ID))
+			err = d.Set("policy_id", p.ID)
+			if err != nil {
+				return errors.Wrapf(err, "Could not set 'policy_id' for Rackcorp firewall policy '%s'.", d.Id())
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("Could not find the new firewall policy on Rackcorp device with id '%d'.", deviceID)
+	}
+
+	return refreshDeviceFirewall(deviceID, config, timeout)
+}
+
+func resourceRackcorpFirewallPolicyCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+
+	err := addStandaloneFirewallPolicy(d, nil, config, d.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return err
+	}
+
+	return resourceRackcorpFirewallPolicyRead(d, meta)
+}
+
+func resourceRackcorpFirewallPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+
+	deviceID, policyID, err := parseFirewallPolicyID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	policies, err := getDeviceFirewallPolicies(deviceID, config)
+	if err != nil {
+		if errors.Is(err, errAPINotFound) {
+			log.Printf("[WARN] Rackcorp device '%d' not found.", deviceID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	for _, p := range policies {
+		if p.ID != policyID {
+			continue
+		}
+
+		values := map[string]interface{}{
+			"device_id":       deviceID,
+			"policy_id":       p.ID,
+			"direction":       p.Direction,
+			"policy":          p.Policy,
+			"order":           p.Order,
+			"comment":         p.Comment,
+			"ip_address_from": p.IpAddressFrom,
+			"ip_address_to":   p.IpAddressTo,
+			"port_from":       p.PortFrom,
+			"port_to":         p.PortTo,
+			"protocol":        p.Protocol,
+		}
+		for key, value := range values {
+			err = d.Set(key, value)
+			if err != nil {
+				return errors.Wrapf(err, "Could not set '%s' for Rackcorp firewall policy '%s'.", key, d.Id())
+			}
+		}
+		return nil
+	}
+
+	log.Printf("[WARN] Rackcorp firewall policy '%d' not found on device '%d'.", policyID, deviceID)
+	d.SetId("")
+	return nil
+}
+
+func resourceRackcorpFirewallPolicyUpdate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+
+	// As with the firewall_policies of a server, a changed policy is replaced
+	// by deleting the old policy and adding a new one in the same request.
+	old := translateOldStandaloneFirewallPolicy(d)
+	old.Policy = "DELETED"
+
+	err := addStandaloneFirewallPolicy(d, []api.FirewallPolicy{old}, config, d.Timeout(schema.TimeoutUpdate))
+	if err != nil {
+		return err
+	}
+
+	return resourceRackcorpFirewallPolicyRead(d, meta)
+}
+
+func resourceRackcorpFirewallPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+	deviceID := d.Get("device_id").(int)
+	policy := translateStandaloneFirewallPolicy(d)
+	policy.Policy = "DELETED"
+
+	err := updateDeviceFirewall(deviceID, []api.FirewallPolicy{policy}, config, d.Timeout(schema.TimeoutDelete))
+	if errors.Is(err, errAPINotFound) {
+		log.Printf("[WARN] Rackcorp device '%d' not found, firewall policy already removed.", deviceID)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package rackcorp
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+func TestResourceRackcorpFirewallPolicyUpdate(t *testing.T) {
+	defer skipWaitDelay()()
+
+	testCases := []struct {
+		name   string
+		key    string
+		value  string
+		policy api.FirewallPolicy
+	}{
+		{"changed port_to", "port_to", "2222", api.FirewallPolicy{PortFrom: "22", PortTo: "2222"}},
+		// port_from is not compared by isFirewallSame, so the old policy still
+		// matches the new one.
+		{"changed port_from", "port_from", "2200", api.FirewallPolicy{PortFrom: "2200", PortTo: "22"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeClient()
+			client.addDevice(42, "ONLINE", api.FirewallPolicy{
+				Direction: api.FirewallPolicyDirectionInbound,
+				Policy:    api.FirewallPolicyTypeAllow,
+				Protocol:  "TCP",
+				PortFrom:  "22",
+				PortTo:    "22",
+				Order:     1,
+			})
+			oldID := client.devices[42].FirewallPolicies[0].ID
+			meta := providerConfig{Client: client}
+
+			state := &terraform.InstanceState{
+				ID: firewallPolicyID(42, oldID),
+				Attributes: map[string]string{
+					"id":        firewallPolicyID(42, oldID),
+					"device_id": "42",
+					"policy_id": strconv.Itoa(oldID),
+					"direction": api.FirewallPolicyDirectionInbound,
+					"policy":    api.FirewallPolicyTypeAllow,
+					"protocol":  "TCP",
+					"port_from": "22",
+					"port_to":   "22",
+					"order":     "1",
+				},
+			}
+
+			raw := map[string]interface{}{
+				"device_id": 42,
+				"direction": api.FirewallPolicyDirectionInbound,
+				"policy":    api.FirewallPolicyTypeAllow,
+				"protocol":  "TCP",
+				"port_from": "22",
+				"port_to":   "22",
+				"order":     1,
+			}
+			raw[tc.key] = tc.value
+
+			resource := resourceRackcorpFirewallPolicy()
+			diff, err := resource.Diff(state, newTestResourceConfig(t, raw), meta)
+			if err != nil {
+				t.Fatalf("Diff() failed: %s", err)
+			}
+
+			state, err = resource.Apply(state, diff, meta)
+			if err != nil {
+				t.Fatalf("Apply() failed: %s", err)
+			}
+
+			policies := client.devices[42].FirewallPolicies
+			if len(policies) != 1 {
+				t.Fatalf("device has %d firewall policies, want 1: %#v", len(policies), policies)
+			}
+			got := policies[0]
+			if got.ID == oldID {
+				t.Errorf("policy id = %d, want a new policy", got.ID)
+			}
+			if got.PortFrom != tc.policy.PortFrom || got.PortTo != tc.policy.PortTo {
+				t.Errorf("policy ports = %s-%s, want %s-%s", got.PortFrom, got.PortTo, tc.policy.PortFrom, tc.policy.PortTo)
+			}
+			if want := firewallPolicyID(42, got.ID); state.ID != want {
+				t.Errorf("id = %q, want %q", state.ID, want)
+			}
+			if got := client.firewallUpdates[42]; got != 1 {
+				t.Errorf("sent %d firewall updates, want 1", got)
+			}
+		})
+	}
+}
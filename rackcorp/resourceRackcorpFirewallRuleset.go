@@ -0,0 +1,148 @@
+package rackcorp
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// resourceRackcorpFirewallRuleset defines a set of firewall rules once so
+// that rackcorp_firewall_ruleset_attachment can apply it to many devices.
+// Rackcorp has no ruleset object, so the rules only live in state and are
+// handed to attachments through the policies_json attribute.
+func resourceRackcorpFirewallRuleset() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceRackcorpFirewallRulesetCreate,
+		Delete:        resourceRackcorpFirewallRulesetDelete,
+		Read:          resourceRackcorpFirewallRulesetRead,
+		Update:        resourceRackcorpFirewallRulesetUpdate,
+		CustomizeDiff: resourceRackcorpFirewallRulesetCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"firewall_policies": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem:     firewallPolicySchemaElement(),
+			},
+			"policies_json": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// encodeFirewallRuleset returns the policies as JSON in a stable order.
+func encodeFirewallRuleset(list []interface{}) (string, error) {
+	policies := parseFirewallPolicies(list)
+	for index := range policies {
+		policies[index].ID = 0
+	}
+
+	encoded := make([]string, len(policies))
+	for index, policy := range policies {
+		b, err := json.Marshal(policy)
+		if err != nil {
+			return "", errors.Wrap(err, "Could not encode firewall policy.")
+		}
+		encoded[index] = string(b)
+	}
+	sort.Sort(byEncoding{policies, encoded})
+
+	b, err := json.Marshal(policies)
+	if err != nil {
+		return "", errors.Wrap(err, "Could not encode firewall ruleset.")
+	}
+	return string(b), nil
+}
+
+func decodeFirewallRuleset(policiesJSON string) ([]api.FirewallPolicy, error) {
+	var policies []api.FirewallPolicy
+	if policiesJSON == "" {
+		return policies, nil
+	}
+
+	err := json.Unmarshal([]byte(policiesJSON), &policies)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not decode firewall ruleset.")
+	}
+	return policies, nil
+}
+
+type byEncoding struct {
+	policies []api.FirewallPolicy
+	encoded  []string
+}
+
+func (s byEncoding) Len() int {
+	return len(s.policies)
+}
+
+func (s byEncoding) Less(i, j int) bool {
+	return s.encoded[i] < s.encoded[j]
+}
+
+func (s byEncoding) Swap(i, j int) {
+	s.policies[i], s.policies[j] = s.policies[j], s.policies[i]
+	s.encoded[i], s.encoded[j] = s.encoded[j], s.encoded[i]
+}
+
+// Attachments interpolate policies_json, so its new value has to be part of
+// the plan rather than appear during apply.
+func resourceRackcorpFirewallRulesetCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("firewall_policies") {
+		return d.SetNewComputed("policies_json")
+	}
+
+	list, err := convertFirewallPoliciesToSlice(d.Get("firewall_policies"))
+	if err != nil {
+		return err
+	}
+
+	policiesJSON, err := encodeFirewallRuleset(list)
+	if err != nil {
+		return err
+	}
+
+	if policiesJSON != d.Get("policies_json").(string) {
+		return d.SetNew("policies_json", policiesJSON)
+	}
+	return nil
+}
+
+func resourceRackcorpFirewallRulesetCreate(d *schema.ResourceData, meta interface{}) error {
+	d.SetId(resource.UniqueId())
+	return resourceRackcorpFirewallRulesetRead(d, meta)
+}
+
+func resourceRackcorpFirewallRulesetRead(d *schema.ResourceData, meta interface{}) error {
+	list, err := convertFirewallPoliciesToSlice(d.Get("firewall_policies"))
+	if err != nil {
+		return errors.Wrapf(err, "Could not read firewall_policies of Rackcorp firewall ruleset '%s'.", d.Id())
+	}
+
+	policiesJSON, err := encodeFirewallRuleset(list)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(d.Set("policies_json", policiesJSON),
+		"Could not set 'policies_json' for Rackcorp firewall ruleset '%s'.", d.Id())
+}
+
+func resourceRackcorpFirewallRulesetUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceRackcorpFirewallRulesetRead(d, meta)
+}
+
+func resourceRackcorpFirewallRulesetDelete(d *schema.ResourceData, meta interface{}) error {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,359 @@
+package rackcorp
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// resourceRackcorpFirewallRulesetAttachment applies the rules of a
+// rackcorp_firewall_ruleset to a list of devices. Changes to the ruleset or
+// the devices are sent as one firewall update per device, followed by a
+// refreshConfig for every changed device once all updates are accepted.
+//
+// applied_policies records the rules applied to each device, so that a
+// partly failed update is retried on the next apply and rules removed from a
+// device outside of Terraform show up as a change to device_ids.
+//
+// As with rackcorp_firewall_policy, servers with attached rulesets must set
+// external_firewall_policies.
+func resourceRackcorpFirewallRulesetAttachment() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRackcorpFirewallRulesetAttachmentCreate,
+		Delete: resourceRackcorpFirewallRulesetAttachmentDelete,
+		Read:   resourceRackcorpFirewallRulesetAttachmentRead,
+		Update: resourceRackcorpFirewallRulesetAttachmentUpdate,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"ruleset_policies": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.ValidateJsonString,
+			},
+			"device_ids": {
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+				Set:      schema.HashInt,
+			},
+			"applied_policies": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func convertSetToInts(set interface{}) []int {
+	list := set.(*schema.Set).List()
+	result := make([]int, len(list))
+	for index, item := range list {
+		result[index] = item.(int)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func convertAppliedPolicies(applied interface{}) map[string]string {
+	result := map[string]string{}
+	for key, value := range applied.(map[string]interface{}) {
+		result[key] = value.(string)
+	}
+	return result
+}
+
+// firewallPoliciesMissingFrom returns the policies of first that are not in
+// second, matching identical policies one to one.
+func firewallPoliciesMissingFrom(first []api.FirewallPolicy, second []api.FirewallPolicy) []api.FirewallPolicy {
+	matched := make([]bool, len(second))
+	result := []api.FirewallPolicy{}
+	for _, policy := range first {
+		found := false
+		for index, other := range second {
+			if !matched[index] && isFirewallSame(policy, other) {
+				matched[index] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, policy)
+		}
+	}
+	return result
+}
+
+// claimFirewallPolicy returns a policy of the device matching policy that has
+// not been claimed yet, so that identical rules are matched one to one.
+func claimFirewallPolicy(current []api.FirewallPolicy, policy api.FirewallPolicy, claimed map[int]bool) (api.FirewallPolicy, bool) {
+	for _, existing := range current {
+		if !claimed[existing.ID] && isFirewallSame(existing, policy) {
+			claimed[existing.ID] = true
+			return existing, true
+		}
+	}
+	return api.FirewallPolicy{}, false
+}
+
+// unclaimedFirewallPolicies returns the policies that are not on the device.
+func unclaimedFirewallPolicies(current []api.FirewallPolicy, policies []api.FirewallPolicy) []api.FirewallPolicy {
+	claimed := map[int]bool{}
+	result := []api.FirewallPolicy{}
+	for _, policy := range policies {
+		if _, ok := claimFirewallPolicy(current, policy, claimed); !ok {
+			result = append(result, policy)
+		}
+	}
+	return result
+}
+
+// deviceFirewallChanges returns the firewall update that changes the ruleset
+// policies on a device from applied to wanted. Policies already on the device
+// are not added again and ones no longer on it are not removed, so a failed
+// update can be retried.
+func deviceFirewallChanges(deviceID int, applied []api.FirewallPolicy, wanted []api.FirewallPolicy, config providerConfig) ([]api.FirewallPolicy, error) {
+	current, err := getDeviceFirewallPolicies(deviceID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []api.FirewallPolicy{}
+	claimed := map[int]bool{}
+	missing := []api.FirewallPolicy{}
+	for _, policy := range wanted {
+		if _, ok := claimFirewallPolicy(current, policy, claimed); !ok {
+			missing = append(missing, policy)
+		}
+	}
+
+	for _, policy := range firewallPoliciesMissingFrom(applied, wanted) {
+		if existing, ok := claimFirewallPolicy(current, policy, claimed); ok {
+			existing.Policy = "DELETED"
+			changes = append(changes, existing)
+		}
+	}
+
+	for _, policy := range missing {
+		policy.ID = 0
+		changes = append(changes, policy)
+	}
+
+	return changes, nil
+}
+
+// applyRuleset changes the ruleset policies on every device in applied or
+// devices to policiesJSON, removing them from devices no longer attached. It
+// returns the policies applied to each device, which only includes the
+// devices that were updated and refreshed when an error is returned.
+func applyRuleset(applied map[string]string, policiesJSON string, devices []int, config providerConfig, timeout time.Duration) (map[string]string, error) {
+	wanted, err := decodeFirewallRuleset(policiesJSON)
+	if err != nil {
+		return applied, err
+	}
+
+	result := map[string]string{}
+	attached := map[string]bool{}
+	targets := []int{}
+	for key, value := range applied {
+		result[key] = value
+		deviceID, err := strconv.Atoi(key)
+		if err != nil {
+			return applied, errors.Wrapf(err, "Invalid device id '%s' in applied_policies.", key)
+		}
+		targets = append(targets, deviceID)
+	}
+	for _, deviceID := range devices {
+		key := strconv.Itoa(deviceID)
+		attached[key] = true
+		if _, ok := applied[key]; !ok {
+			targets = append(targets, deviceID)
+		}
+	}
+	sort.Ints(targets)
+
+	var updateErr error
+	refresh := []int{}
+	for _, deviceID := range targets {
+		key := strconv.Itoa(deviceID)
+		target := ""
+		if attached[key] {
+			target = policiesJSON
+		}
+
+		previous, err := decodeFirewallRuleset(applied[key])
+		if err != nil {
+			updateErr = err
+			break
+		}
+		next := wanted
+		if !attached[key] {
+			next = nil
+		}
+
+		changes, err := deviceFirewallChanges(deviceID, previous, next, config)
+		if err == nil && len(changes) > 0 {
+			err = config.Client.DeviceUpdateFirewall(deviceID, changes)
+			if err != nil {
+				err = errors.Wrapf(classifyAPIError(err), "Failed to update firewall policies for device id '%d'.", deviceID)
+			}
+		}
+		if errors.Is(err, errAPINotFound) && !attached[key] {
+			log.Printf("[WARN] Rackcorp device '%d' not found, firewall ruleset already removed.", deviceID)
+			delete(result, key)
+			continue
+		}
+		if err != nil {
+			updateErr = err
+			break
+		}
+
+		// Changes accepted by an earlier failed apply may not have been
+		// refreshed, so refresh whenever the applied policies change.
+		if len(changes) > 0 || applied[key] != target {
+			refresh = append(refresh, deviceID)
+		}
+		if target == "" {
+			delete(result, key)
+		} else {
+			result[key] = target
+		}
+	}
+
+	// Devices updated before a failure still need their changes applied.
+	err = refreshDeviceConfigs(refresh, config, timeout)
+	if err != nil {
+		for _, deviceID := range refresh {
+			key := strconv.Itoa(deviceID)
+			if value, ok := applied[key]; ok {
+				result[key] = value
+			} else {
+				delete(result, key)
+			}
+		}
+		if updateErr != nil {
+			log.Printf("[WARN] %s", err)
+			return result, updateErr
+		}
+		return result, err
+	}
+
+	return result, updateErr
+}
+
+func resourceRackcorpFirewallRulesetAttachmentCreate(d *schema.ResourceData, meta interface{}) error {
+	// Keep the attachment in state when some devices fail so that the rules
+	// already added are tracked by applied_policies.
+	d.SetId(resource.UniqueId())
+	return resourceRackcorpFirewallRulesetAttachmentUpdate(d, meta)
+}
+
+func resourceRackcorpFirewallRulesetAttachmentRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+	applied := convertAppliedPolicies(d.Get("applied_policies"))
+	policiesJSON := d.Get("ruleset_policies").(string)
+
+	devices := []int{}
+	for key, value := range applied {
+		deviceID, err := strconv.Atoi(key)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid device id '%s' in applied_policies.", key)
+		}
+
+		policies, err := decodeFirewallRuleset(value)
+		if err != nil {
+			return err
+		}
+
+		current, err := getDeviceFirewallPolicies(deviceID, config)
+		if err != nil {
+			if errors.Is(err, errAPINotFound) {
+				log.Printf("[WARN] Rackcorp device '%d' not found, removing it from firewall ruleset attachment '%s'.", deviceID, d.Id())
+				delete(applied, key)
+				continue
+			}
+			return err
+		}
+
+		missing := unclaimedFirewallPolicies(current, policies)
+		if len(missing) > 0 {
+			log.Printf("[WARN] %d firewall policies of attachment '%s' are missing from Rackcorp device '%d'.", len(missing), d.Id(), deviceID)
+			delete(applied, key)
+			continue
+		}
+
+		if value == policiesJSON {
+			devices = append(devices, deviceID)
+		}
+	}
+
+	if len(applied) == 0 {
+		log.Printf("[WARN] Firewall ruleset attachment '%s' is not applied to any Rackcorp device.", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	err := d.Set("applied_policies", applied)
+	if err != nil {
+		return errors.Wrapf(err, "Could not set 'applied_policies' for Rackcorp firewall ruleset attachment '%s'.", d.Id())
+	}
+
+	return errors.Wrapf(d.Set("device_ids", devices),
+		"Could not set 'device_ids' for Rackcorp firewall ruleset attachment '%s'.", d.Id())
+}
+
+func resourceRackcorpFirewallRulesetAttachmentUpdate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	if d.IsNewResource() {
+		timeout = d.Timeout(schema.TimeoutCreate)
+	}
+
+	d.Partial(true)
+
+	applied, err := applyRuleset(
+		convertAppliedPolicies(d.Get("applied_policies")),
+		d.Get("ruleset_policies").(string),
+		convertSetToInts(d.Get("device_ids")),
+		config,
+		timeout)
+
+	// Only the devices that were updated are saved when some failed, the
+	// others are retried on the next apply.
+	setErr := d.Set("applied_policies", applied)
+	if setErr != nil {
+		return errors.Wrapf(setErr, "Could not set 'applied_policies' for Rackcorp firewall ruleset attachment '%s'.", d.Id())
+	}
+	d.SetPartial("applied_policies")
+	if err != nil {
+		return err
+	}
+
+	d.Partial(false)
+	return resourceRackcorpFirewallRulesetAttachmentRead(d, meta)
+}
+
+func resourceRackcorpFirewallRulesetAttachmentDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(providerConfig)
+
+	_, err := applyRuleset(
+		convertAppliedPolicies(d.Get("applied_policies")),
+		d.Get("ruleset_policies").(string),
+		nil,
+		config,
+		d.Timeout(schema.TimeoutDelete))
+	return err
+}
@@ -0,0 +1,247 @@
+package rackcorp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+func newTestFirewallPolicy(port string, order int) api.FirewallPolicy {
+	return api.FirewallPolicy{
+		Direction: api.FirewallPolicyDirectionInbound,
+		Policy:    api.FirewallPolicyTypeAllow,
+		Protocol:  "TCP",
+		PortFrom:  port,
+		PortTo:    port,
+		Order:     order,
+	}
+}
+
+var (
+	testPolicySSH   = newTestFirewallPolicy("22", 1)
+	testPolicyHTTP  = newTestFirewallPolicy("80", 2)
+	testPolicyHTTPS = newTestFirewallPolicy("443", 3)
+	// testPolicyOther stands for a rule managed outside of the attachment.
+	testPolicyOther = newTestFirewallPolicy("8080", 9)
+)
+
+func newTestRulesetJSON(t *testing.T, policies ...api.FirewallPolicy) string {
+	b, err := json.Marshal(policies)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %s", err)
+	}
+	return string(b)
+}
+
+// firewallPolicyKeys describes policies without their ids, in a stable order.
+func firewallPolicyKeys(policies []api.FirewallPolicy) []string {
+	keys := []string{}
+	for _, p := range policies {
+		keys = append(keys, fmt.Sprintf("%s %s %s %s-%s #%d", p.Direction, p.Policy, p.Protocol, p.PortFrom, p.PortTo, p.Order))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestUnclaimedFirewallPolicies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		current  []api.FirewallPolicy
+		policies []api.FirewallPolicy
+		want     []api.FirewallPolicy
+	}{
+		{"all present", []api.FirewallPolicy{testPolicySSH, testPolicyHTTP}, []api.FirewallPolicy{testPolicySSH}, nil},
+		{"one missing", []api.FirewallPolicy{testPolicySSH}, []api.FirewallPolicy{testPolicySSH, testPolicyHTTP}, []api.FirewallPolicy{testPolicyHTTP}},
+		{"duplicate present once", []api.FirewallPolicy{testPolicySSH}, []api.FirewallPolicy{testPolicySSH, testPolicySSH}, []api.FirewallPolicy{testPolicySSH}},
+		{"duplicate present twice", []api.FirewallPolicy{testPolicySSH, testPolicySSH}, []api.FirewallPolicy{testPolicySSH, testPolicySSH}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Give the device policies ids as Rackcorp would.
+			current := make([]api.FirewallPolicy, len(tc.current))
+			for index, policy := range tc.current {
+				policy.ID = 1000 + index
+				current[index] = policy
+			}
+
+			got := unclaimedFirewallPolicies(current, tc.policies)
+			if !reflect.DeepEqual(firewallPolicyKeys(got), firewallPolicyKeys(tc.want)) {
+				t.Errorf("unclaimedFirewallPolicies() = %v, want %v", firewallPolicyKeys(got), firewallPolicyKeys(tc.want))
+			}
+		})
+	}
+}
+
+func TestApplyRuleset(t *testing.T) {
+	defer skipWaitDelay()()
+
+	type devicePolicies map[int][]api.FirewallPolicy
+
+	testCases := []struct {
+		name        string
+		devices     devicePolicies
+		applied     devicePolicies
+		ruleset     []api.FirewallPolicy
+		attach      []int
+		want        devicePolicies
+		wantApplied []int
+		wantUpdates map[int]int
+	}{
+		{
+			name:        "new attachment",
+			devices:     devicePolicies{42: {testPolicyOther}, 43: nil},
+			ruleset:     []api.FirewallPolicy{testPolicySSH, testPolicyHTTP},
+			attach:      []int{42, 43},
+			want:        devicePolicies{42: {testPolicyOther, testPolicySSH, testPolicyHTTP}, 43: {testPolicySSH, testPolicyHTTP}},
+			wantApplied: []int{42, 43},
+			wantUpdates: map[int]int{42: 1, 43: 1},
+		},
+		{
+			name:        "changed ruleset",
+			devices:     devicePolicies{42: {testPolicyOther, testPolicySSH, testPolicyHTTP}},
+			applied:     devicePolicies{42: {testPolicySSH, testPolicyHTTP}},
+			ruleset:     []api.FirewallPolicy{testPolicySSH, testPolicyHTTPS},
+			attach:      []int{42},
+			want:        devicePolicies{42: {testPolicyOther, testPolicySSH, testPolicyHTTPS}},
+			wantApplied: []int{42},
+			wantUpdates: map[int]int{42: 1},
+		},
+		{
+			name:        "unchanged ruleset",
+			devices:     devicePolicies{42: {testPolicySSH}},
+			applied:     devicePolicies{42: {testPolicySSH}},
+			ruleset:     []api.FirewallPolicy{testPolicySSH},
+			attach:      []int{42},
+			want:        devicePolicies{42: {testPolicySSH}},
+			wantApplied: []int{42},
+			wantUpdates: map[int]int{},
+		},
+		{
+			name:        "device detached",
+			devices:     devicePolicies{42: {testPolicySSH}, 43: {testPolicyOther, testPolicySSH}},
+			applied:     devicePolicies{42: {testPolicySSH}, 43: {testPolicySSH}},
+			ruleset:     []api.FirewallPolicy{testPolicySSH},
+			attach:      []int{42},
+			want:        devicePolicies{42: {testPolicySSH}, 43: {testPolicyOther}},
+			wantApplied: []int{42},
+			wantUpdates: map[int]int{43: 1},
+		},
+		{
+			name:        "detached device not found",
+			devices:     devicePolicies{42: {testPolicySSH}},
+			applied:     devicePolicies{42: {testPolicySSH}, 99: {testPolicySSH}},
+			ruleset:     []api.FirewallPolicy{testPolicySSH},
+			attach:      []int{42},
+			want:        devicePolicies{42: {testPolicySSH}},
+			wantApplied: []int{42},
+			wantUpdates: map[int]int{},
+		},
+		{
+			name:        "duplicate identical rules added",
+			devices:     devicePolicies{42: nil},
+			ruleset:     []api.FirewallPolicy{testPolicySSH, testPolicySSH},
+			attach:      []int{42},
+			want:        devicePolicies{42: {testPolicySSH, testPolicySSH}},
+			wantApplied: []int{42},
+			wantUpdates: map[int]int{42: 1},
+		},
+		{
+			name:        "duplicate identical rule removed",
+			devices:     devicePolicies{42: {testPolicySSH, testPolicySSH}},
+			applied:     devicePolicies{42: {testPolicySSH, testPolicySSH}},
+			ruleset:     []api.FirewallPolicy{testPolicySSH},
+			attach:      []int{42},
+			want:        devicePolicies{42: {testPolicySSH}},
+			wantApplied: []int{42},
+			wantUpdates: map[int]int{42: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeClient()
+			for deviceID, policies := range tc.devices {
+				client.addDevice(deviceID, "ONLINE", policies...)
+			}
+			applied := map[string]string{}
+			for deviceID, policies := range tc.applied {
+				applied[strconv.Itoa(deviceID)] = newTestRulesetJSON(t, policies...)
+			}
+			policiesJSON := newTestRulesetJSON(t, tc.ruleset...)
+
+			result, err := applyRuleset(applied, policiesJSON, tc.attach, providerConfig{Client: client}, time.Minute)
+			if err != nil {
+				t.Fatalf("applyRuleset() failed: %s", err)
+			}
+
+			wantResult := map[string]string{}
+			for _, deviceID := range tc.wantApplied {
+				wantResult[strconv.Itoa(deviceID)] = policiesJSON
+			}
+			if !reflect.DeepEqual(result, wantResult) {
+				t.Errorf("applyRuleset() = %v, want %v", result, wantResult)
+			}
+
+			for deviceID, want := range tc.want {
+				got := client.devices[deviceID].FirewallPolicies
+				if !reflect.DeepEqual(firewallPolicyKeys(got), firewallPolicyKeys(want)) {
+					t.Errorf("device %d policies = %v, want %v", deviceID, firewallPolicyKeys(got), firewallPolicyKeys(want))
+				}
+			}
+
+			if !reflect.DeepEqual(client.firewallUpdates, tc.wantUpdates) {
+				t.Errorf("firewall updates = %v, want %v", client.firewallUpdates, tc.wantUpdates)
+			}
+		})
+	}
+}
+
+func TestApplyRulesetRetryAfterPartialFailure(t *testing.T) {
+	defer skipWaitDelay()()
+
+	client := newFakeClient()
+	client.addDevice(42, "ONLINE")
+	client.addDevice(43, "ONLINE")
+	client.firewallErrors[43] = errors.New("connection reset")
+	config := providerConfig{Client: client}
+	policiesJSON := newTestRulesetJSON(t, testPolicySSH, testPolicyHTTP)
+
+	result, err := applyRuleset(map[string]string{}, policiesJSON, []int{42, 43}, config, time.Minute)
+	if err == nil {
+		t.Fatal("applyRuleset() succeeded, want the error of device 43")
+	}
+	if want := map[string]string{"42": policiesJSON}; !reflect.DeepEqual(result, want) {
+		t.Fatalf("applyRuleset() = %v after a failure, want %v", result, want)
+	}
+	if types := client.transactionTypes("42"); !reflect.DeepEqual(types, []string{api.TransactionTypeRefreshConfig}) {
+		t.Errorf("device 42 transactions = %v, want a refresh of the accepted update", types)
+	}
+
+	result, err = applyRuleset(result, policiesJSON, []int{42, 43}, config, time.Minute)
+	if err != nil {
+		t.Fatalf("applyRuleset() retry failed: %s", err)
+	}
+	if want := map[string]string{"42": policiesJSON, "43": policiesJSON}; !reflect.DeepEqual(result, want) {
+		t.Errorf("applyRuleset() = %v after the retry, want %v", result, want)
+	}
+
+	// The retry leaves the device that was already updated alone.
+	if want := map[int]int{42: 1, 43: 1}; !reflect.DeepEqual(client.firewallUpdates, want) {
+		t.Errorf("firewall updates = %v, want %v", client.firewallUpdates, want)
+	}
+	for _, deviceID := range []int{42, 43} {
+		got := client.devices[deviceID].FirewallPolicies
+		want := []api.FirewallPolicy{testPolicySSH, testPolicyHTTP}
+		if !reflect.DeepEqual(firewallPolicyKeys(got), firewallPolicyKeys(want)) {
+			t.Errorf("device %d policies = %v, want %v", deviceID, firewallPolicyKeys(got), firewallPolicyKeys(want))
+		}
+	}
+}
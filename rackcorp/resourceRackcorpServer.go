@@ -1,8 +1,11 @@
 package rackcorp
 
 import (
+	"encoding/json"
 	"log"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -12,12 +15,24 @@ import (
 	"github.com/section-io/rackcorp-sdk-go/v2"
 )
 
+const (
+	powerStateOn  = "on"
+	powerStateOff = "off"
+
+	createPhaseOrderConfirmed = "ORDER_CONFIRMED"
+	createPhaseContractActive = "CONTRACT_ACTIVE"
+	createPhaseDeviceAssigned = "DEVICE_ASSIGNED"
+	createPhaseStartupSent    = "STARTUP_SENT"
+	createPhaseComplete       = "COMPLETE"
+)
+
 func storageSchemaElement() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"name": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"size_gb": {
 				Type:         schema.TypeInt,
@@ -25,16 +40,18 @@ func storageSchemaElement() *schema.Resource {
 				ValidateFunc: validation.IntAtLeast(1),
 			},
 			"type": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 				ValidateFunc: validation.StringInSlice(
 					api.StorageTypes,
 					false,
 				),
 			},
 			"sort_order": {
-				Type:     schema.TypeInt,
-				Optional: true,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 		},
 	}
@@ -99,12 +116,14 @@ func nicSchemaElement() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"name": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"vlan": {
-				Type:     schema.TypeInt,
-				Optional: true,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"speed": {
 				Type:     schema.TypeInt,
@@ -115,47 +134,65 @@ func nicSchemaElement() *schema.Resource {
 				Required: true,
 			},
 			"pool_ipv4": {
-				Type:     schema.TypeInt,
-				Optional: true,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"ipv6": {
 				Type:     schema.TypeInt,
 				Optional: true,
 			},
 			"pool_ipv6": {
-				Type:     schema.TypeInt,
-				Optional: true,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 		},
 	}
 }
 
 func resourceRackcorpServer() *schema.Resource {
-	return &schema.Resource{
+	result := &schema.Resource{
 		Create: resourceRackcorpServerCreate,
 		Delete: resourceRackcorpServerDelete,
 		Read:   resourceRackcorpServerRead,
 		Update: resourceRackcorpServerUpdate,
+		Importer: &schema.ResourceImporter{
+			State: resourceRackcorpServerImport,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(60 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"country": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"server_class": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 				ValidateFunc: validation.StringInSlice(
 					api.ServerClasses,
 					false,
 				),
 			},
+			// TODO add a rebuild_on_change mode that reinstalls the existing device
+			// when the install and startup data change, once the api package has
+			// a reinstall transaction that accepts api.Install.
 			"operating_system": {
 				Type:     schema.TypeString,
 				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
+			// TODO resize cpu_count and memory_gb in place once the api package
+			// can place a change order against an existing contract.
 			"cpu_count": {
 				Type:     schema.TypeInt,
 				Required: true,
@@ -166,11 +203,15 @@ func resourceRackcorpServer() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+			// TODO rotate in place with a credential update transaction, only
+			// storing the new password once it has completed, when the api
+			// package supports one.
 			"root_password": {
-				Type:      schema.TypeString,
-				Optional:  true,
-				ForceNew:  true,
-				Sensitive: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"data_center_id": {
 				Type:     schema.TypeInt,
@@ -179,18 +220,25 @@ func resourceRackcorpServer() *schema.Resource {
 				ForceNew: true,
 			},
 			"traffic_gb": {
-				Type:     schema.TypeInt,
-				Optional: true,
-				ForceNew: true,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"post_install_script": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
+			// TODO add and grow volumes in place, forcing a new server only when a
+			// volume is removed or shrunk, once the api package can change the
+			// storage on an existing contract.
 			"storage": {
 				Type:     schema.TypeList,
 				Optional: true,
+				Computed: true,
 				ForceNew: true,
 				MinItems: 1,
 				Elem:     storageSchemaElement(),
@@ -202,9 +250,18 @@ func resourceRackcorpServer() *schema.Resource {
 				MinItems: 1,
 				Elem:     firewallPolicySchemaElement(),
 			},
+			// Leaves the rules of the device to rackcorp_firewall_policy and
+			// rackcorp_firewall_ruleset_attachment resources.
+			"external_firewall_policies": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"firewall_policies"},
+			},
 			"nics": {
 				Type:     schema.TypeList,
 				Optional: true,
+				Computed: true,
 				ForceNew: true,
 				MinItems: 1,
 				Elem:     nicSchemaElement(),
@@ -213,12 +270,16 @@ func resourceRackcorpServer() *schema.Resource {
 				Type:     schema.TypeInt,
 				Computed: true,
 			},
+			// TODO rename in place with a device update and refreshConfig once the
+			// api package can update the device hostname.
 			"name": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Computed: true,
 				ForceNew: true,
 			},
+			// TODO expose ipv4_addresses, ipv6_addresses and per-NIC addresses, MAC,
+			// gateway, netmask and VLAN once api.Device decodes ips and ports.
 			"primary_ip": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -239,6 +300,65 @@ func resourceRackcorpServer() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"power_switch": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"power_status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"device_extra": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"power_state": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{powerStateOn, powerStateOff},
+					false,
+				),
+			},
+			"cancel_on_create_failure": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"deletion_protection": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			// TODO add cancellation_mode to cancel at the end of the billing term
+			// once the api package can send data with a CANCEL transaction.
+			"wait_for_cancellation": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"create_phase": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"imported": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			// The configured values hidden by suppressUnknownAfterImport, as JSON,
+			// planned for the first update after an import to record in state.
+			"imported_config": {
+				Type:      schema.TypeMap,
+				Computed:  true,
+				Sensitive: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 			"device_cancel_transaction_id": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -248,9 +368,10 @@ func resourceRackcorpServer() *schema.Resource {
 				Computed: true,
 			},
 			"host_group_id": {
-				Type:     schema.TypeInt,
-				Optional: true,
-				ForceNew: true,
+				Type:             schema.TypeInt,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"location": {
 				Type:     schema.TypeString,
@@ -258,16 +379,36 @@ func resourceRackcorpServer() *schema.Resource {
 				ForceNew: true,
 			},
 			"user_data": {
+				ConflictsWith:    []string{"post_install_script"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
+			},
+			"ssh_authorized_keys": {
 				ConflictsWith: []string{"post_install_script"},
-				Type:          schema.TypeString,
+				Type:          schema.TypeList,
 				Optional:      true,
 				ForceNew:      true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateSSHAuthorizedKey,
+				},
+				DiffSuppressFunc: suppressUnknownAfterImport,
+			},
+			"ssh_username": {
+				ConflictsWith:    []string{"post_install_script"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"meta_data": {
-				ConflictsWith: []string{"post_install_script"},
-				Type:          schema.TypeString,
-				Optional:      true,
-				ForceNew:      true,
+				ConflictsWith:    []string{"post_install_script"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"deploy_media_image_access_key": {
 				ConflictsWith: []string{"post_install_script"},
@@ -281,30 +422,39 @@ func resourceRackcorpServer() *schema.Resource {
 				Sensitive:     true,
 			},
 			"deploy_media_image_bucket": {
-				ConflictsWith: []string{"deploy_media_image_id", "post_install_script", "root_password"},
-				Type:          schema.TypeString,
-				Optional:      true,
-				ForceNew:      true,
+				ConflictsWith:    []string{"deploy_media_image_id", "post_install_script", "root_password"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"deploy_media_image_id": {
-				ConflictsWith: []string{"post_install_script", "root_password"},
-				Type:          schema.TypeString,
-				Optional:      true,
-				ForceNew:      true,
+				ConflictsWith:    []string{"post_install_script", "root_password"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"deploy_media_image_path": {
-				ConflictsWith: []string{"post_install_script"},
-				Type:          schema.TypeString,
-				Optional:      true,
-				ForceNew:      true,
+				ConflictsWith:    []string{"post_install_script"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 			"timezone": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnknownAfterImport,
 			},
 		},
 	}
+
+	result.CustomizeDiff = resourceRackcorpServerCustomizeDiff(result.Schema)
+
+	return result
 }
 
 func resourceRackcorpServerPopulateFromDevice(d *schema.ResourceData, config providerConfig) error {
@@ -317,34 +467,195 @@ func resourceRackcorpServerPopulateFromDevice(d *schema.ResourceData, config pro
 
 	device, err := config.Client.DeviceGet(deviceID)
 	if err != nil {
-		if apiErr, ok := err.(*api.ApiError); ok {
-			if apiErr.Message == "Could not find device" {
-				return newNotFoundError(apiErr.Message)
-			}
-		}
-		return errors.Wrapf(err, "Could not get Rackcorp device with id '%d'.", deviceID)
+		return errors.Wrapf(classifyAPIError(err), "Could not get Rackcorp device with id '%d'.", deviceID)
 	}
 
 	log.Printf("[DEBUG] Rackcorp device: %#v", device)
 
-	panicOnError(d.Set("name", device.Name))
-	panicOnError(d.Set("primary_ip", device.PrimaryIP))
-	panicOnError(d.Set("data_center_id", device.DataCenterId))
-	panicOnError(d.Set("firewall_policies", convertFirewallToMap(device.FirewallPolicies)))
-
 	powerSwitch := getExtraByKey("SYS_POWERSWITCH", device.Extra)
+	powerStatus := getExtraByKey("SYS_POWERSTATUS", device.Extra)
+
+	deviceStatus := powerSwitch
 	if powerSwitch == "ONLINE" {
-		powerStatus := getExtraByKey("SYS_POWERSTATUS", device.Extra)
 		log.Printf("[TRACE] Rackcorp device power status: %s", powerStatus)
-		panicOnError(d.Set("device_status", powerStatus))
+		deviceStatus = powerStatus
 	} else {
 		log.Printf("[TRACE] Rackcorp device power switch: %s", powerSwitch)
-		panicOnError(d.Set("device_status", powerSwitch))
 	}
 
+	values := map[string]interface{}{
+		"name":              device.Name,
+		"primary_ip":        device.PrimaryIP,
+		"data_center_id":    device.DataCenterId,
+		"firewall_policies": convertFirewallToMap(device.FirewallPolicies),
+		"device_extra":      convertExtraToStringMap(device.Extra),
+		"power_switch":      powerSwitch,
+		"power_status":      powerStatus,
+		"device_status":     deviceStatus,
+	}
+
+	if d.Get("external_firewall_policies").(bool) {
+		values["firewall_policies"] = []interface{}{}
+	}
+
+	// The device is OFFLINE until an interrupted create has started it, which
+	// must not be recorded as a configured power state for the resumed create.
+	if phase := d.Get("create_phase").(string); phase == "" || phase == createPhaseComplete {
+		switch deviceStatus {
+		case "ONLINE":
+			values["power_state"] = powerStateOn
+		case "OFFLINE":
+			values["power_state"] = powerStateOff
+		}
+	}
+
+	err = setAttributes(d, values)
+	if err != nil {
+		return err
+	}
+
+	return resourceRackcorpServerPopulateFromDeviceExtra(d, device.Extra)
+}
+
+// setAttribute sets a single attribute, naming the attribute, order and device
+// in the error so that a schema mismatch fails the operation rather than the
+// whole provider.
+func setAttribute(d *schema.ResourceData, key string, value interface{}) error {
+	err := d.Set(key, value)
+	if err != nil {
+		return errors.Wrapf(err, "Could not set '%s' for Rackcorp order '%s' with device id '%d'.",
+			key, d.Id(), d.Get("device_id").(int))
+	}
+	return nil
+}
+
+func setAttributes(d *schema.ResourceData, values map[string]interface{}) error {
+	for key, value := range values {
+		err := setAttribute(d, key, value)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// resourceRackcorpServerPopulateFromDeviceExtra reads the product details
+// that Rackcorp reports in the device extra data so that changes made outside
+// of Terraform show up in a plan. Keys missing from the extra data leave the
+// current value in place.
+func resourceRackcorpServerPopulateFromDeviceExtra(d *schema.ResourceData, extras map[string]interface{}) error {
+	values := map[string]interface{}{}
+
+	if location := getExtraByKey("LOCATION", extras); location != "" {
+		values["location"] = location
+	}
+
+	if cpuCount, ok := getExtraIntByKey("CPU", extras); ok {
+		values["cpu_count"] = cpuCount
+	}
+
+	if memoryGB, ok := getExtraIntByKey("MEMORYGB", extras); ok {
+		values["memory_gb"] = memoryGB
+	}
+
+	// Custom images are reported with an empty operating system.
+	if operatingSystem := getExtraByKey("OPERATINGSYSTEM", extras); operatingSystem != "" {
+		values["operating_system"] = operatingSystem
+	}
+
+	if trafficGB, ok := getExtraIntByKey("TRAFFICGB", extras); ok {
+		values["traffic_gb"] = trafficGB
+	}
+
+	if timezone := getExtraByKey("TIMEZONE", extras); timezone != "" {
+		values["timezone"] = timezone
+	}
+
+	current, _ := d.Get("storage").([]interface{})
+	if storage := convertExtraToStorage(extras, current); len(storage) > 0 {
+		values["storage"] = storage
+	}
+
+	current, _ = d.Get("nics").([]interface{})
+	if nics := convertExtraToNics(extras, current); len(nics) > 0 {
+		values["nics"] = nics
+	}
+
+	return setAttributes(d, values)
+}
+
+// convertExtraToStorage returns the storage reported by Rackcorp, keeping the
+// name, type and sort order of the current storage as those are not reported.
+func convertExtraToStorage(extras map[string]interface{}, current []interface{}) []interface{} {
+	var sizes []int
+
+	// Virtual disks are reported as VMHDD1, VMHDD2, ... in megabytes.
+	for index := 1; ; index++ {
+		sizeMB, ok := getExtraIntByKey("VMHDD"+strconv.Itoa(index), extras)
+		if !ok {
+			break
+		}
+		sizes = append(sizes, sizeMB/1024)
+	}
+
+	// STORAGEGB is the total of all disks so only describes a single disk.
+	if len(sizes) == 0 && len(current) <= 1 {
+		if sizeGB, ok := getExtraIntByKey("STORAGEGB", extras); ok {
+			sizes = append(sizes, sizeGB)
+		}
+	}
+
+	var result []interface{}
+	for index, sizeGB := range sizes {
+		storage := map[string]interface{}{}
+		if index < len(current) {
+			if existing, ok := current[index].(map[string]interface{}); ok {
+				for k, v := range existing {
+					storage[k] = v
+				}
+			}
+		}
+		storage["size_gb"] = sizeGB
+		result = append(result, storage)
+	}
+
+	return result
+}
+
+// convertExtraToNics returns the NIC reported by Rackcorp, keeping the name,
+// VLAN and pool sizes of the current NIC as those are not reported. The extra
+// data only describes a single port so servers with several NICs are left as
+// they are.
+func convertExtraToNics(extras map[string]interface{}, current []interface{}) []interface{} {
+	if len(current) > 1 {
+		return nil
+	}
+
+	speed, ok := getExtraIntByKey("PORTSPEED", extras)
+	if !ok {
+		return nil
+	}
+
+	nic := map[string]interface{}{}
+	if len(current) == 1 {
+		if existing, ok := current[0].(map[string]interface{}); ok {
+			for k, v := range existing {
+				nic[k] = v
+			}
+		}
+	}
+
+	nic["speed"] = speed
+	if ipv4, ok := getExtraIntByKey("IPV4", extras); ok {
+		nic["ipv4"] = ipv4
+	}
+	if ipv6, ok := getExtraIntByKey("IPV6", extras); ok {
+		nic["ipv6"] = ipv6
+	}
+
+	return []interface{}{nic}
+}
+
 func convertFirewallToMap(fwList []api.FirewallPolicy) *schema.Set {
 	resultList := schema.NewSet(schema.HashResource(firewallPolicySchemaElement()), []interface{}{})
 	for _, v := range fwList {
@@ -375,18 +686,24 @@ func resourceRackcorpServerPopulateFromContract(d *schema.ResourceData, config p
 
 	contract, err := config.Client.OrderContractGet(contractID)
 	if err != nil {
-		return errors.Wrapf(err, "Could not get Rackcorp contract with id '%s'.", contractID)
+		return errors.Wrapf(classifyAPIError(err), "Could not get Rackcorp contract with id '%s'.", contractID)
 	}
 
 	log.Printf("[DEBUG] Rackcorp contract: %#v", contract)
 
-	panicOnError(d.Set("contract_status", contract.Status))
+	err = setAttribute(d, "contract_status", contract.Status)
+	if err != nil {
+		return err
+	}
 	if contract.DeviceId != "" { // DeviceId can be blank for pending contracts
 		intID, err := strconv.Atoi(contract.DeviceId)
 		if err != nil {
 			return errors.Wrap(err, "Could not get Rackcorp contract device ID as integer'.")
 		}
-		panicOnError(d.Set("device_id", intID))
+		err = setAttribute(d, "device_id", intID)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -402,14 +719,12 @@ func resourceRackcorpServerPopulateFromTransaction(d *schema.ResourceData, confi
 
 	transaction, err := config.Client.TransactionGet(cancelTransactionID)
 	if err != nil {
-		return errors.Wrapf(err, "Could not get Rackcorp transaction with id '%s'.", cancelTransactionID)
+		return errors.Wrapf(classifyAPIError(err), "Could not get Rackcorp transaction with id '%s'.", cancelTransactionID)
 	}
 
 	log.Printf("[DEBUG] Rackcorp transaction: %#v", transaction)
 
-	panicOnError(d.Set("device_cancel_transaction_status", transaction.Status))
-
-	return nil
+	return setAttribute(d, "device_cancel_transaction_status", transaction.Status)
 }
 
 func getExtraByKey(key string, extras map[string]interface{}) string {
@@ -426,6 +741,35 @@ func getExtraByKey(key string, extras map[string]interface{}) string {
 	return ""
 }
 
+func convertExtraToStringMap(extras map[string]interface{}) map[string]string {
+	result := map[string]string{}
+	for key, extra := range extras {
+		switch v := extra.(type) {
+		case string:
+			result[key] = v
+		case float64:
+			result[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			result[key] = strconv.FormatBool(v)
+		}
+	}
+	return result
+}
+
+func getExtraIntByKey(key string, extras map[string]interface{}) (int, bool) {
+	switch extra := extras[key].(type) {
+	case string:
+		i, err := strconv.Atoi(extra)
+		if err != nil {
+			return 0, false
+		}
+		return i, true
+	case float64:
+		return int(extra), true
+	}
+	return 0, false
+}
+
 func startServer(deviceID int, d *schema.ResourceData, config providerConfig) error {
 	stringID := strconv.Itoa(deviceID)
 	data := api.TransactionStartupData{}
@@ -446,9 +790,11 @@ func startServer(deviceID int, d *schema.ResourceData, config providerConfig) er
 		data.DeployMediaImageAccessSecret = accessSecret.(string)
 	}
 
-	if userData, ok := d.GetOk("user_data"); ok {
-		data.CloudInit.UserData = userData.(string)
+	userData, err := cloudInitUserData(d)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to build cloud-init user data for device id '%d'.", deviceID)
 	}
+	data.CloudInit.UserData = userData
 
 	if metaData, ok := d.GetOk("meta_data"); ok {
 		data.CloudInit.MetaData = metaData.(string)
@@ -465,6 +811,72 @@ func startServer(deviceID int, d *schema.ResourceData, config providerConfig) er
 	return nil
 }
 
+// powerOnServer starts a device that has already been provisioned. Unlike
+// startServer it sends no startup data, so the deploy media image and
+// cloud-init are not applied again.
+func powerOnServer(deviceID int, config providerConfig) error {
+	stringID := strconv.Itoa(deviceID)
+	transaction, err := config.Client.TransactionCreate(
+		api.TransactionTypeStartup,
+		api.TransactionObjectTypeDevice,
+		stringID,
+		true)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to power on server with device id '%d'.", deviceID)
+	}
+
+	log.Printf("[TRACE] Created transaction '%s' to power on server with device id '%d'.",
+		transaction.TransactionId, deviceID)
+
+	return nil
+}
+
+func stopServer(deviceID int, config providerConfig) error {
+	stringID := strconv.Itoa(deviceID)
+	transaction, err := config.Client.TransactionCreate(
+		api.TransactionTypeShutdown,
+		api.TransactionObjectTypeDevice,
+		stringID,
+		true)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to shut down server with device id '%d'.", deviceID)
+	}
+
+	log.Printf("[TRACE] Created transaction '%s' to shut down server with device id '%d'.",
+		transaction.TransactionId, deviceID)
+
+	return nil
+}
+
+func applyPowerState(
+	deviceID int, powerState string, d *schema.ResourceData, config providerConfig, timeout time.Duration) error {
+
+	switch powerState {
+	case powerStateOn:
+		err := powerOnServer(deviceID, config)
+		if err != nil {
+			return err
+		}
+
+		err = waitForDeviceAttribute(d, config, "device_status", "ONLINE", []string{"OFFLINE"}, timeout)
+		if err != nil {
+			return errors.Wrap(err, "Error waiting for Rackcorp device status to be ONLINE")
+		}
+	case powerStateOff:
+		err := stopServer(deviceID, config)
+		if err != nil {
+			return err
+		}
+
+		err = waitForDeviceAttribute(d, config, "device_status", "OFFLINE", []string{"ONLINE"}, timeout)
+		if err != nil {
+			return errors.Wrap(err, "Error waiting for Rackcorp device status to be OFFLINE")
+		}
+	}
+
+	return nil
+}
+
 func cancelServer(deviceID int, d *schema.ResourceData, config providerConfig) error {
 	stringID := strconv.Itoa(deviceID)
 	transaction, err := config.Client.TransactionCreate(
@@ -476,9 +888,21 @@ func cancelServer(deviceID int, d *schema.ResourceData, config providerConfig) e
 		return errors.Wrapf(err, "Failed to request server cancellation for device id '%d'.", deviceID)
 	}
 
-	panicOnError(d.Set("device_cancel_transaction_id", transaction.TransactionId))
+	err = setAttribute(d, "device_cancel_transaction_id", transaction.TransactionId)
+	if err != nil {
+		return err
+	}
+
+	// Servers created before this setting existed have no value in state and
+	// keep waiting.
+	if wait, ok := d.GetOkExists("wait_for_cancellation"); ok && !wait.(bool) {
+		log.Printf("[TRACE] Created transaction '%s' to cancel server with device id '%d', not waiting for it to complete.",
+			transaction.TransactionId, deviceID)
+		return nil
+	}
 
-	err = waitForTransactionAttribute(d, config, "device_cancel_transaction_status", "COMPLETED", []string{"PENDING", "COMMENCED"})
+	err = waitForTransactionAttribute(d, config, "device_cancel_transaction_status", "COMPLETED", []string{"PENDING", "COMMENCED"},
+		d.Timeout(schema.TimeoutDelete))
 
 	if err != nil {
 		return errors.Wrapf(err, "Failed to cancel server with device id '%d'.", deviceID)
@@ -490,17 +914,17 @@ func cancelServer(deviceID int, d *schema.ResourceData, config providerConfig) e
 	return nil
 }
 
-func translateFirewallPolicy(d *schema.ResourceData) []api.FirewallPolicy {
+func translateFirewallPolicy(d *schema.ResourceData) ([]api.FirewallPolicy, error) {
 	var result []api.FirewallPolicy
 	list, ok := d.GetOk("firewall_policies")
 	if !ok {
-		return result
+		return result, nil
 	}
-	schemaList, ok := list.(*schema.Set)
-	if !ok {
-		panic(errors.New("Error casing firewall policies to schema.Set type"))
+	asList, err := convertFirewallPoliciesToSlice(list)
+	if err != nil {
+		return nil, err
 	}
-	return parseFirewallPolicies((*schemaList).List())
+	return parseFirewallPolicies(asList), nil
 }
 
 func parseFirewallPolicies(list []interface{}) []api.FirewallPolicy {
@@ -628,27 +1052,29 @@ func translateNic(d *schema.ResourceData) []api.Nic {
 
 func resourceRackcorpServerCreate(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(providerConfig)
+	powerState := d.Get("power_state").(string)
+	deadline := time.Now().Add(d.Timeout(schema.TimeoutCreate))
 
-	extraRefreshRequired := true
 	install := api.Install{}
 	if os, ok := d.GetOk("operating_system"); ok {
 		install.OperatingSystem = os.(string)
-
-		// Rackcorp willl remove the need for this in future API
-		// revisions. From email 2018-04-30.
-		extraRefreshRequired = false
 	}
 	if script, ok := d.GetOk("post_install_script"); ok {
 		install.PostInstallScript = script.(string)
 	}
 
+	firewallPolicies, err := translateFirewallPolicy(d)
+	if err != nil {
+		return err
+	}
+
 	productDetails := api.ProductDetails{
 		Install:          install,
 		CpuCount:         d.Get("cpu_count").(int),
 		Location:         d.Get("location").(string),
 		MemoryGB:         d.Get("memory_gb").(int),
 		Storage:          translateStorage(d),
-		FirewallPolicies: translateFirewallPolicy(d),
+		FirewallPolicies: firewallPolicies,
 		Nics:             translateNic(d),
 	}
 
@@ -688,6 +1114,8 @@ func resourceRackcorpServerCreate(d *schema.ResourceData, meta interface{}) erro
 		d.Get("country").(string),
 	)
 
+	defer config.acquireOrderSlot()()
+
 	createdOrder, err := config.Client.OrderCreate(productCode, config.CustomerID, productDetails)
 	if err != nil {
 		return errors.Wrap(err, "Rackcorp order create request failed")
@@ -703,51 +1131,105 @@ func resourceRackcorpServerCreate(d *schema.ResourceData, meta interface{}) erro
 
 	contractCount := len(confirmedOrder.ContractIds)
 	if contractCount != 1 {
-		return errors.Errorf("Expected one Rackcorp contract for order '%s' but received %d", orderID, contractCount)
+		err = errors.Errorf("Expected one Rackcorp contract for order '%s' but received %d", orderID, contractCount)
+		return cancelFailedCreate(d, config, err)
 	}
 
 	contractID := confirmedOrder.ContractIds[0]
 
-	panicOnError(d.Set("contract_id", contractID))
+	err = setAttributes(d, map[string]interface{}{
+		"contract_id":  contractID,
+		"create_phase": createPhaseOrderConfirmed,
+	})
+	if err != nil {
+		return cancelFailedCreate(d, config, err)
+	}
 
-	err = waitForContractStatus(d, config, "ACTIVE", []string{"PENDING"})
+	err = resourceRackcorpServerResumeCreate(d, config, createPhaseOrderConfirmed, powerState, deadline)
 	if err != nil {
-		return errors.Wrap(err, "Error waiting for Rackcorp contract status to be ACTIVE")
+		return cancelFailedCreate(d, config, err)
 	}
 
-	deviceID := d.Get("device_id").(int)
+	return resourceRackcorpServerRead(d, meta)
+}
 
-	if extraRefreshRequired {
-		err := performRefreshConfig(deviceID, config)
+// resourceRackcorpServerResumeCreate runs the steps of a create that follow
+// the given phase, recording each phase as it completes so that a failed or
+// timed out create can carry on from the same point on the next apply. Every
+// step has to finish before the deadline of the operation.
+func resourceRackcorpServerResumeCreate(
+	d *schema.ResourceData, config providerConfig, phase string, powerState string, deadline time.Time) error {
+
+	setPhase := func(phase string) error {
+		d.SetPartial("create_phase")
+		return setAttribute(d, "create_phase", phase)
+	}
+
+	switch phase {
+	case createPhaseOrderConfirmed:
+		err := waitForContractStatus(d, config, "ACTIVE", []string{"PENDING"}, time.Until(deadline))
+		if err != nil {
+			return errors.Wrap(err, "Error waiting for Rackcorp contract status to be ACTIVE")
+		}
+		err = setPhase(createPhaseContractActive)
 		if err != nil {
-			log.Println("[WARN] Request to refresh configuration before starting server failed.")
 			return err
 		}
-	}
+		fallthrough
 
-	err = waitForPendingDeviceTransactions(deviceID, config)
-	if err != nil {
-		return errors.Wrap(err, "Error waiting for Rackcorp device transactions to complete")
-	}
+	case createPhaseContractActive:
+		deviceID := d.Get("device_id").(int)
+		if deviceID == 0 {
+			return errors.Errorf("Rackcorp contract '%s' is active but has no device.", d.Get("contract_id").(string))
+		}
 
-	err = startServer(deviceID, d, config)
-	if err != nil {
-		return err
-	}
+		// Rackcorp willl remove the need for this in future API
+		// revisions. From email 2018-04-30.
+		if _, ok := d.GetOk("operating_system"); !ok {
+			err := performRefreshConfig(deviceID, config)
+			if err != nil {
+				log.Println("[WARN] Request to refresh configuration before starting server failed.")
+				return err
+			}
+		}
 
-	err = waitForDeviceAttribute(d, config, "device_status", "ONLINE", []string{"OFFLINE"})
-	if err != nil {
-		return errors.Wrap(err, "Error waiting for Rackcorp device status to be ONLINE")
-	}
+		err := waitForPendingDeviceTransactions(deviceID, config, time.Until(deadline))
+		if err != nil {
+			return errors.Wrap(err, "Error waiting for Rackcorp device transactions to complete")
+		}
+		err = setPhase(createPhaseDeviceAssigned)
+		if err != nil {
+			return err
+		}
+		fallthrough
 
-	return resourceRackcorpServerRead(d, meta)
-}
+	case createPhaseDeviceAssigned:
+		err := startServer(d.Get("device_id").(int), d, config)
+		if err != nil {
+			return err
+		}
+		err = setPhase(createPhaseStartupSent)
+		if err != nil {
+			return err
+		}
+		fallthrough
 
-func panicOnError(err error) {
-	if err == nil {
-		return
+	case createPhaseStartupSent:
+		err := waitForDeviceAttribute(d, config, "device_status", "ONLINE", []string{"OFFLINE"}, time.Until(deadline))
+		if err != nil {
+			return errors.Wrap(err, "Error waiting for Rackcorp device status to be ONLINE")
+		}
+
+		if powerState == powerStateOff {
+			err = applyPowerState(d.Get("device_id").(int), powerState, d, config, time.Until(deadline))
+			if err != nil {
+				return err
+			}
+		}
+		return setPhase(createPhaseComplete)
 	}
-	panic(err)
+
+	return nil
 }
 
 func resourceRackcorpServerRead(d *schema.ResourceData, meta interface{}) error {
@@ -760,6 +1242,12 @@ func resourceRackcorpServerRead(d *schema.ResourceData, meta interface{}) error
 
 	order, err := config.Client.OrderGet(orderID)
 	if err != nil {
+		err = classifyAPIError(err)
+		if errors.Is(err, errAPINotFound) {
+			log.Printf("[WARN] Rackcorp order '%s' not found.", orderID)
+			d.SetId("")
+			return nil
+		}
 		return errors.Wrapf(err, "Error retrieving Rackcorp order '%s'.", orderID)
 	}
 
@@ -769,16 +1257,31 @@ func resourceRackcorpServerRead(d *schema.ResourceData, meta interface{}) error
 		d.SetId("")
 		return nil
 	}
-	panicOnError(d.Set("contract_id", contractID))
+	err = setAttribute(d, "contract_id", contractID)
+	if err != nil {
+		return err
+	}
+
+	// imported_config only carries values in the plan of an update.
+	err = setAttribute(d, "imported_config", map[string]interface{}{})
+	if err != nil {
+		return err
+	}
 
 	err = resourceRackcorpServerPopulateFromContract(d, config)
 	if err != nil {
+		if errors.Is(err, errAPINotFound) {
+			log.Printf("[WARN] Rackcorp contract '%s' not found.", contractID)
+			d.SetId("")
+			return nil
+		}
 		return err
 	}
 
 	err = resourceRackcorpServerPopulateFromDevice(d, config)
 	if err != nil {
-		if _, ok := err.(*notFoundError); ok {
+		if errors.Is(err, errAPINotFound) {
+			log.Printf("[WARN] Rackcorp device for order '%s' not found.", orderID)
 			d.SetId("")
 			return nil
 		}
@@ -788,27 +1291,301 @@ func resourceRackcorpServerRead(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
-func convertFirewallPoliciesToSlice(firewallPol interface{}) []interface{} {
+// The product code and the inputs only used when the server was ordered or
+// started are not returned by the Rackcorp API, so they are unknown for an
+// imported server and the configured values must not force a replacement.
+// The first update after the import records the configured values through
+// imported_config, after which changes to them are planned as usual. Servers
+// created by Terraform keep these values in state, so setting one later still
+// replaces the server.
+func suppressUnknownAfterImport(k, old, new string, d *schema.ResourceData) bool {
+	imported, _ := d.GetChange("imported")
+	// Lists and the numbers in storage and nics are read back as "0".
+	return imported.(bool) && (old == "" || old == "0")
+}
+
+func resourceRackcorpServerImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	config := meta.(providerConfig)
+	importID := d.Id()
+
+	_, err := config.Client.OrderGet(importID)
+	if err != nil {
+		err = classifyAPIError(err)
+		// TODO import by device id once the api package can look up the order
+		// that created a device, until then a device id only gives a hint.
+		if deviceID, convErr := strconv.Atoi(importID); convErr == nil {
+			if _, deviceErr := config.Client.DeviceGet(deviceID); deviceErr == nil {
+				return nil, errors.Errorf(
+					"'%s' is a Rackcorp device id, import requires the id of the order that created the device.", importID)
+			}
+		}
+		return nil, errors.Wrapf(err, "Could not import Rackcorp order '%s'.", importID)
+	}
+
+	// Defaults are not applied on import, set them so that a configuration
+	// leaving them out plans no changes.
+	err = setAttributes(d, map[string]interface{}{
+		"imported":                   true,
+		"cancel_on_create_failure":   false,
+		"deletion_protection":        false,
+		"external_firewall_policies": false,
+		"wait_for_cancellation":      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = resourceRackcorpServerRead(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, errors.Errorf("Rackcorp order '%s' does not have a contract.", importID)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func convertFirewallPoliciesToSlice(firewallPol interface{}) ([]interface{}, error) {
 	asSet, ok := firewallPol.(*schema.Set)
 	if !ok {
-		panic(errors.New("Error casing firewall policies to schema.Set type"))
+		return nil, errors.Errorf("Error casting firewall policies of type %T to schema.Set type", firewallPol)
 	}
 	asList := (*asSet).List()
-	return asList
+	return asList, nil
+}
+
+// cancelFailedCreate cancels the device of a confirmed order whose create
+// failed when cancel_on_create_failure is set. The create error is always
+// returned, annotated with the outcome of the cancellation.
+func cancelFailedCreate(d *schema.ResourceData, config providerConfig, createErr error) error {
+	if !d.Get("cancel_on_create_failure").(bool) {
+		return createErr
+	}
+
+	// The device may have been assigned since the contract was last read.
+	err := resourceRackcorpServerPopulateFromContract(d, config)
+	if err != nil {
+		log.Printf("[WARN] Could not refresh Rackcorp contract before cancelling failed create: %s", err)
+	}
+
+	deviceID := d.Get("device_id").(int)
+	if deviceID == 0 {
+		// The api package has no request to cancel an order or contract.
+		return errors.Wrapf(createErr,
+			"Rackcorp order '%s' has no device to cancel and must be cancelled in the Rackcorp portal", d.Id())
+	}
+
+	log.Printf("[INFO] Cancelling Rackcorp device '%d' after create failed", deviceID)
+	err = cancelServer(deviceID, d, config)
+	if err != nil {
+		return errors.Wrapf(createErr,
+			"Failed to cancel Rackcorp device '%d' after create failed (%s)", deviceID, err)
+	}
+
+	d.SetId("")
+	return errors.Wrapf(createErr, "Cancelled Rackcorp device '%d' after create failed", deviceID)
+}
+
+// changedKeyForcesNew reports whether a key of the planned diff below the
+// ForceNew attribute key replaces the resource. It follows helper/schema:
+// lists and maps are replaced when their count changes, elements of primitive
+// lists inherit ForceNew and fields of nested resources only force a new
+// resource when they are ForceNew themselves.
+func changedKeyForcesNew(key string, attribute *schema.Schema, changed string) bool {
+	if changed == key || changed == key+".#" || changed == key+".%" {
+		return true
+	}
+	if !strings.HasPrefix(changed, key+".") {
+		return false
+	}
+
+	elem, ok := attribute.Elem.(*schema.Resource)
+	if !ok {
+		return true
+	}
+
+	// Nested keys are <key>.<index>.<field>[.<nested>].
+	parts := strings.SplitN(strings.TrimPrefix(changed, key+"."), ".", 3)
+	if len(parts) < 2 {
+		return true
+	}
+	field, ok := elem.Schema[parts[1]]
+	return ok && field.ForceNew
+}
+
+// hasDiffSuppressFunc reports whether the attribute or one of its nested
+// fields has a DiffSuppressFunc.
+func hasDiffSuppressFunc(attribute *schema.Schema) bool {
+	if attribute.DiffSuppressFunc != nil {
+		return true
+	}
+	elem, ok := attribute.Elem.(*schema.Resource)
+	if !ok {
+		return false
+	}
+	for _, field := range elem.Schema {
+		if field.DiffSuppressFunc != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// planImportedConfig plans to record the configured values of keys in
+// imported_config, so that the update clears imported without losing them.
+func planImportedConfig(d *schema.ResourceDiff, keys []string) error {
+	values := map[string]interface{}{}
+	for _, key := range keys {
+		// Unlike the planned diff, HasChange includes suppressed changes.
+		if !d.HasChange(key) {
+			continue
+		}
+		b, err := json.Marshal(d.Get(key))
+		if err != nil {
+			return errors.Wrapf(err, "Could not encode '%s' for Rackcorp server with device id '%d'.", key, d.Get("device_id").(int))
+		}
+		values[key] = string(b)
+	}
+
+	if len(values) > 0 {
+		err := d.SetNew("imported_config", values)
+		if err != nil {
+			return err
+		}
+	}
+	return d.SetNew("imported", false)
+}
+
+// resourceRackcorpServerCustomizeDiff returns the CustomizeDiff of a server
+// with the given schema.
+func resourceRackcorpServerCustomizeDiff(schemaMap map[string]*schema.Schema) schema.CustomizeDiffFunc {
+	var forceNewKeys, importKeys []string
+	for key, attribute := range schemaMap {
+		if attribute.ForceNew {
+			forceNewKeys = append(forceNewKeys, key)
+		}
+		if hasDiffSuppressFunc(attribute) {
+			importKeys = append(importKeys, key)
+		}
+	}
+	sort.Strings(forceNewKeys)
+	sort.Strings(importKeys)
+
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		if d.Id() == "" {
+			return nil
+		}
+
+		// Use the value from state so that protection has to be turned off in
+		// an apply of its own before the server can be replaced.
+		protected, _ := d.GetChange("deletion_protection")
+		if protected.(bool) {
+			var changedKeys []string
+			// Only keys in the planned diff are checked, unlike HasChange this
+			// leaves out changes hidden by a DiffSuppressFunc.
+			for _, key := range forceNewKeys {
+				for _, changed := range d.GetChangedKeysPrefix(key) {
+					if changedKeyForcesNew(key, schemaMap[key], changed) {
+						changedKeys = append(changedKeys, key)
+						break
+					}
+				}
+			}
+			if len(changedKeys) > 0 {
+				return errors.Errorf(
+					"Changing %s requires replacing Rackcorp server with device id '%d' which has deletion_protection enabled.",
+					strings.Join(changedKeys, ", "), d.Get("device_id").(int))
+			}
+		}
+
+		// An imported server only records the values Rackcorp does not report
+		// once it is updated, so that the plan after an import is empty.
+		imported, _ := d.GetChange("imported")
+		if imported.(bool) && len(d.GetChangedKeysPrefix("")) > 0 {
+			err := planImportedConfig(d, importKeys)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Plan to finish a create that did not complete. Servers created before
+		// create phases were recorded have no phase and are already complete.
+		phase := d.Get("create_phase").(string)
+		if phase != "" && phase != createPhaseComplete {
+			return d.SetNew("create_phase", createPhaseComplete)
+		}
+
+		return nil
+	}
+}
+
+// recordImportedConfig stores the configured values planned in
+// imported_config by the first update after an import.
+func recordImportedConfig(d *schema.ResourceData) error {
+	values := map[string]interface{}{}
+	for key, value := range d.Get("imported_config").(map[string]interface{}) {
+		var decoded interface{}
+		err := json.Unmarshal([]byte(value.(string)), &decoded)
+		if err != nil {
+			return errors.Wrapf(err, "Could not decode imported_config '%s' for Rackcorp order '%s'.", key, d.Id())
+		}
+		values[key] = decoded
+	}
+	values["imported_config"] = map[string]interface{}{}
+
+	err := setAttributes(d, values)
+	if err != nil {
+		return err
+	}
+	for key := range values {
+		d.SetPartial(key)
+	}
+	d.SetPartial("imported")
+	return nil
 }
 
 func resourceRackcorpServerUpdate(d *schema.ResourceData, meta interface{}) error {
-	log.Print("[INFO] Updating the server(s), only firewall policies are updateable in this version")
+	log.Print("[INFO] Updating the server(s), only firewall policies and power state are updateable in this version")
 	config := meta.(providerConfig)
 	deviceID := d.Get("device_id").(int)
 	isConfigDirty := false
+	createResumed := false
+	deadline := time.Now().Add(d.Timeout(schema.TimeoutUpdate))
 
 	d.Partial(true)
 
-	if d.HasChange("firewall_policies") {
+	if d.HasChange("imported") {
+		err := recordImportedConfig(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("create_phase") {
+		phase, _ := d.GetChange("create_phase")
+		log.Printf("[INFO] Resuming create of Rackcorp order '%s' after phase %s", d.Id(), phase)
+		err := resourceRackcorpServerResumeCreate(d, config, phase.(string), d.Get("power_state").(string), deadline)
+		if err != nil {
+			return cancelFailedCreate(d, config, err)
+		}
+		deviceID = d.Get("device_id").(int)
+		createResumed = true
+	}
+
+	if d.HasChange("firewall_policies") && !d.Get("external_firewall_policies").(bool) {
 		old, new := d.GetChange("firewall_policies")
-		newPolicies := parseFirewallPolicies(convertFirewallPoliciesToSlice(new))
-		oldPolicies := parseFirewallPolicies(convertFirewallPoliciesToSlice(old))
+		newList, err := convertFirewallPoliciesToSlice(new)
+		if err != nil {
+			return errors.Wrapf(err, "Could not read new firewall_policies for device id '%d'.", deviceID)
+		}
+		oldList, err := convertFirewallPoliciesToSlice(old)
+		if err != nil {
+			return errors.Wrapf(err, "Could not read old firewall_policies for device id '%d'.", deviceID)
+		}
+		newPolicies := parseFirewallPolicies(newList)
+		oldPolicies := parseFirewallPolicies(oldList)
 		requestPolicies := []api.FirewallPolicy{}
 		for _, newPolicy := range newPolicies {
 			if !arrayContains(oldPolicies, newPolicy) {
@@ -824,7 +1601,7 @@ func resourceRackcorpServerUpdate(d *schema.ResourceData, meta interface{}) erro
 				requestPolicies = append(requestPolicies, oldPolicy)
 			}
 		}
-		err := config.Client.DeviceUpdateFirewall(deviceID, requestPolicies)
+		err = config.Client.DeviceUpdateFirewall(deviceID, requestPolicies)
 		if err != nil {
 			log.Println("[INFO] ERROR on update request")
 			return err
@@ -835,16 +1612,19 @@ func resourceRackcorpServerUpdate(d *schema.ResourceData, meta interface{}) erro
 
 	if isConfigDirty {
 		log.Print("[INFO] Server config is dirty, sending refreshConfig transaction")
-		err := performRefreshConfig(deviceID, config)
+		err := refreshDeviceFirewall(deviceID, config, time.Until(deadline))
 		if err != nil {
-			log.Println("[WARN] Request to refresh configuration after firewall changes failed, changes have not been applied.")
 			return err
 		}
-		err = waitForPendingDeviceTransactions(deviceID, config)
+	}
+
+	// A resumed create has already applied the configured power state.
+	if d.HasChange("power_state") && !createResumed {
+		err := applyPowerState(deviceID, d.Get("power_state").(string), d, config, time.Until(deadline))
 		if err != nil {
-			log.Println("[WARN] Attempt to refresh configuration after firewall changes failed, changes have not been applied.")
 			return err
 		}
+		d.SetPartial("power_state")
 	}
 
 	d.Partial(false)
@@ -869,6 +1649,35 @@ func performRefreshConfig(deviceID int, config providerConfig) error {
 	return nil
 }
 
+// refreshDeviceFirewall applies accepted policy changes to a device.
+func refreshDeviceFirewall(deviceID int, config providerConfig, timeout time.Duration) error {
+	return refreshDeviceConfigs([]int{deviceID}, config, timeout)
+}
+
+// refreshDeviceConfigs sends a refreshConfig transaction to every device
+// before waiting on any of them, so that the devices apply their changes in
+// parallel.
+func refreshDeviceConfigs(deviceIDs []int, config providerConfig, timeout time.Duration) error {
+	for _, deviceID := range deviceIDs {
+		err := performRefreshConfig(deviceID, config)
+		if err != nil {
+			log.Println("[WARN] Request to refresh configuration after firewall changes failed, changes have not been applied.")
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, deviceID := range deviceIDs {
+		err := waitForPendingDeviceTransactions(deviceID, config, time.Until(deadline))
+		if err != nil {
+			log.Println("[WARN] Attempt to refresh configuration after firewall changes failed, changes have not been applied.")
+			return err
+		}
+	}
+
+	return nil
+}
+
 func arrayContains(arr []api.FirewallPolicy, item api.FirewallPolicy) bool {
 	for _, thing := range arr {
 		if isFirewallSame(thing, item) {
@@ -888,6 +1697,13 @@ func isFirewallSame(first, second api.FirewallPolicy) bool {
 func resourceRackcorpServerDelete(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(providerConfig)
 	deviceID := d.Get("device_id").(int)
+
+	protected, _ := d.GetChange("deletion_protection")
+	if protected.(bool) {
+		return errors.Errorf(
+			"Rackcorp server with device id '%d' has deletion_protection enabled, disable it in a separate apply before deleting the server.",
+			deviceID)
+	}
 	err := cancelServer(deviceID, d, config)
 	if err != nil {
 		return err
@@ -895,7 +1711,13 @@ func resourceRackcorpServerDelete(d *schema.ResourceData, meta interface{}) erro
 	return nil
 }
 
-func waitForContractStatus(d *schema.ResourceData, config providerConfig, targetStatus string, pendingStatuses []string) error {
+// waitDelay gives Rackcorp time to act on a request before a waiter first
+// checks its result.
+var waitDelay = 10 * time.Second
+
+func waitForContractStatus(
+	d *schema.ResourceData, config providerConfig, targetStatus string, pendingStatuses []string, timeout time.Duration) error {
+
 	log.Printf(
 		"[INFO] Waiting for contract to have Status of %s",
 		targetStatus)
@@ -904,8 +1726,8 @@ func waitForContractStatus(d *schema.ResourceData, config providerConfig, target
 		Pending:    pendingStatuses,
 		Target:     []string{targetStatus},
 		Refresh:    newContractStatusRefreshFunc(d, config),
-		Timeout:    60 * time.Minute,
-		Delay:      10 * time.Second,
+		Timeout:    timeout,
+		Delay:      waitDelay,
 		MinTimeout: 3 * time.Second,
 	}
 
@@ -929,7 +1751,7 @@ func newContractStatusRefreshFunc(d *schema.ResourceData, config providerConfig)
 }
 
 func waitForDeviceAttribute(
-	d *schema.ResourceData, config providerConfig, attribute string, target string, pending []string) error {
+	d *schema.ResourceData, config providerConfig, attribute string, target string, pending []string, timeout time.Duration) error {
 
 	log.Printf(
 		"[INFO] Waiting for device to have %s of %s",
@@ -939,8 +1761,8 @@ func waitForDeviceAttribute(
 		Pending:    pending,
 		Target:     []string{target},
 		Refresh:    newDeviceStateRefreshFunc(d, config, attribute),
-		Timeout:    60 * time.Minute,
-		Delay:      10 * time.Second,
+		Timeout:    timeout,
+		Delay:      waitDelay,
 		MinTimeout: 3 * time.Second,
 	}
 
@@ -965,7 +1787,7 @@ func newDeviceStateRefreshFunc(d *schema.ResourceData, config providerConfig, at
 }
 
 func waitForTransactionAttribute(
-	d *schema.ResourceData, config providerConfig, attribute string, target string, pending []string) error {
+	d *schema.ResourceData, config providerConfig, attribute string, target string, pending []string, timeout time.Duration) error {
 
 	log.Printf(
 		"[INFO] Waiting for transaction to have %s of %s",
@@ -975,8 +1797,8 @@ func waitForTransactionAttribute(
 		Pending:    pending,
 		Target:     []string{target},
 		Refresh:    newTransactionStateRefreshFunc(d, config, attribute),
-		Timeout:    60 * time.Minute,
-		Delay:      10 * time.Second,
+		Timeout:    timeout,
+		Delay:      waitDelay,
 		MinTimeout: 3 * time.Second,
 	}
 
@@ -1000,13 +1822,13 @@ func newTransactionStateRefreshFunc(d *schema.ResourceData, config providerConfi
 	}
 }
 
-func waitForPendingDeviceTransactions(deviceID int, config providerConfig) error {
+func waitForPendingDeviceTransactions(deviceID int, config providerConfig, timeout time.Duration) error {
 	stateConf := &resource.StateChangeConf{
 		Pending:    []string{api.TransactionStatusPending},
 		Target:     []string{api.TransactionStatusCompleted},
 		Refresh:    newPendingTransactionsRefreshFunc(deviceID, config),
-		Timeout:    60 * time.Minute,
-		Delay:      10 * time.Second,
+		Timeout:    timeout,
+		Delay:      waitDelay,
 		MinTimeout: 3 * time.Second,
 	}
 
@@ -0,0 +1,336 @@
+package rackcorp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+// skipWaitDelay removes the delay before waiters first poll and returns the
+// function that restores it.
+func skipWaitDelay() func() {
+	delay := waitDelay
+	waitDelay = 0
+	return func() {
+		waitDelay = delay
+	}
+}
+
+func newTestResourceConfig(t *testing.T, raw map[string]interface{}) *terraform.ResourceConfig {
+	rawConfig, err := config.NewRawConfig(raw)
+	if err != nil {
+		t.Fatalf("config.NewRawConfig(%v) failed: %s", raw, err)
+	}
+	return terraform.NewResourceConfig(rawConfig)
+}
+
+func TestResourceRackcorpServerDeletionProtection(t *testing.T) {
+	// country and server_class are not known after an import.
+	attributes := map[string]string{
+		"id":                  "1234",
+		"device_id":           "42",
+		"cpu_count":           "2",
+		"memory_gb":           "4",
+		"location":            "SYD1",
+		"storage.#":           "0",
+		"nics.#":              "0",
+		"deletion_protection": "true",
+		"imported":            "true",
+	}
+
+	testCases := []struct {
+		name    string
+		state   map[string]string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "suppressed change after import",
+			config: map[string]interface{}{
+				"country":             "AU",
+				"server_class":        "PERFORMANCE",
+				"cpu_count":           2,
+				"memory_gb":           4,
+				"location":            "SYD1",
+				"deletion_protection": true,
+			},
+		},
+		{
+			name: "change forcing a new server",
+			config: map[string]interface{}{
+				"country":             "AU",
+				"server_class":        "PERFORMANCE",
+				"cpu_count":           4,
+				"memory_gb":           4,
+				"location":            "SYD1",
+				"deletion_protection": true,
+			},
+			wantErr: "Changing cpu_count requires replacing",
+		},
+		{
+			name: "in-place change of a replacing attribute",
+			state: map[string]string{
+				"storage.#":         "1",
+				"storage.0.size_gb": "50",
+				"storage.0.type":    "",
+			},
+			config: map[string]interface{}{
+				"country":      "AU",
+				"server_class": "PERFORMANCE",
+				"cpu_count":    2,
+				"memory_gb":    4,
+				"location":     "SYD1",
+				"storage": []interface{}{
+					map[string]interface{}{"size_gb": 50, "type": "SSD"},
+				},
+				"deletion_protection": true,
+			},
+		},
+		{
+			name: "added element of a replacing attribute",
+			config: map[string]interface{}{
+				"country":      "AU",
+				"server_class": "PERFORMANCE",
+				"cpu_count":    2,
+				"memory_gb":    4,
+				"location":     "SYD1",
+				"storage": []interface{}{
+					map[string]interface{}{"size_gb": 50},
+				},
+				"deletion_protection": true,
+			},
+			wantErr: "Changing storage requires replacing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := &terraform.InstanceState{ID: "1234", Attributes: map[string]string{}}
+			for key, value := range attributes {
+				state.Attributes[key] = value
+			}
+			for key, value := range tc.state {
+				state.Attributes[key] = value
+			}
+
+			_, err := resourceRackcorpServer().Diff(state, newTestResourceConfig(t, tc.config), nil)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("Diff() failed: %s", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("Diff() error = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// newTestImportedServerState imports the server of newTestServerClient,
+// whose device reports the product details but not the inputs used to order
+// and start it.
+func newTestImportedServerState(t *testing.T, client *fakeClient) *terraform.InstanceState {
+	extras := map[string]interface{}{
+		"LOCATION":  "SYD1",
+		"CPU":       "2",
+		"MEMORYGB":  "4",
+		"STORAGEGB": "50",
+		"PORTSPEED": "1000",
+		"IPV4":      "1",
+	}
+	for key, value := range extras {
+		client.devices[42].Extra[key] = value
+	}
+
+	d := resourceRackcorpServer().Data(&terraform.InstanceState{ID: "1234"})
+	_, err := resourceRackcorpServerImport(d, providerConfig{Client: client})
+	if err != nil {
+		t.Fatalf("resourceRackcorpServerImport() failed: %s", err)
+	}
+	return d.State()
+}
+
+func newTestImportedServerConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"country":             "AU",
+		"server_class":        "PERFORMANCE",
+		"cpu_count":           2,
+		"memory_gb":           4,
+		"location":            "SYD1",
+		"root_password":       "secret",
+		"traffic_gb":          1000,
+		"host_group_id":       7,
+		"user_data":           "#cloud-config\n",
+		"ssh_authorized_keys": []interface{}{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGN0dGVzdGtleWZvcnRlcnJhZm9ybXByb3ZpZGVy test"},
+		"ssh_username":        "deploy",
+		"meta_data":           "{}",
+		"timezone":            "Australia/Sydney",
+		"storage": []interface{}{
+			map[string]interface{}{"size_gb": 50, "type": "SSD", "name": "root", "sort_order": 1},
+		},
+		"nics": []interface{}{
+			map[string]interface{}{"speed": 1000, "ipv4": 1, "name": "public", "vlan": 100, "pool_ipv4": 3},
+		},
+	}
+}
+
+func TestSuppressUnknownAfterImport(t *testing.T) {
+	t.Run("imported server", func(t *testing.T) {
+		state := newTestImportedServerState(t, newTestServerClient("ONLINE"))
+		diff, err := resourceRackcorpServer().Diff(state, newTestResourceConfig(t, newTestImportedServerConfig()), nil)
+		if err != nil {
+			t.Fatalf("Diff() failed: %s", err)
+		}
+		if !diff.Empty() {
+			t.Errorf("Diff() = %#v, want an empty diff", diff.Attributes)
+		}
+	})
+
+	t.Run("created server", func(t *testing.T) {
+		state := newTestImportedServerState(t, newTestServerClient("ONLINE"))
+		state.Attributes["imported"] = "false"
+		state.Attributes["country"] = "AU"
+		state.Attributes["server_class"] = "PERFORMANCE"
+
+		diff, err := resourceRackcorpServer().Diff(state, newTestResourceConfig(t, newTestImportedServerConfig()), nil)
+		if err != nil {
+			t.Fatalf("Diff() failed: %s", err)
+		}
+		if !diff.RequiresNew() {
+			t.Errorf("Diff().RequiresNew() = false, want true, diff: %#v", diff.Attributes)
+		}
+	})
+}
+
+func TestResourceRackcorpServerFirstUpdateAfterImport(t *testing.T) {
+	defer skipWaitDelay()()
+
+	client := newTestServerClient("ONLINE")
+	meta := providerConfig{Client: client}
+	raw := newTestImportedServerConfig()
+	raw["power_state"] = powerStateOff
+
+	server := resourceRackcorpServer()
+	state := newTestImportedServerState(t, client)
+	diff, err := server.Diff(state, newTestResourceConfig(t, raw), meta)
+	if err != nil {
+		t.Fatalf("Diff() failed: %s", err)
+	}
+	if diff.RequiresNew() {
+		t.Fatalf("Diff().RequiresNew() = true, want false, diff: %#v", diff.Attributes)
+	}
+
+	state, err = server.Apply(state, diff, meta)
+	if err != nil {
+		t.Fatalf("Apply() failed: %s", err)
+	}
+
+	want := map[string]string{
+		"country":               "AU",
+		"traffic_gb":            "1000",
+		"ssh_authorized_keys.#": "1",
+		"storage.0.type":        "SSD",
+		"storage.0.sort_order":  "1",
+		"nics.0.vlan":           "100",
+		"imported":              "false",
+		"imported_config.%":     "0",
+		"power_state":           powerStateOff,
+	}
+	for key, value := range want {
+		if got := state.Attributes[key]; got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	// Later changes are planned once the configured values are recorded.
+	raw["country"] = "NZ"
+	diff, err = server.Diff(state, newTestResourceConfig(t, raw), meta)
+	if err != nil {
+		t.Fatalf("Diff() failed: %s", err)
+	}
+	if !diff.RequiresNew() {
+		t.Errorf("Diff().RequiresNew() = false after changing country, want true, diff: %#v", diff.Attributes)
+	}
+}
+
+func newTestServerClient(powerStatus string) *fakeClient {
+	client := newFakeClient()
+	client.orders["1234"] = &api.Order{OrderId: "1234", ContractId: "5678"}
+	client.contracts["5678"] = &api.OrderContract{ContractId: "5678", DeviceId: "42", Status: rackcorpAPIOrderContractStatusActive}
+	client.addDevice(42, powerStatus)
+	return client
+}
+
+func newTestServerState(phase string) *terraform.InstanceState {
+	return &terraform.InstanceState{
+		ID: "1234",
+		Attributes: map[string]string{
+			"id":           "1234",
+			"contract_id":  "5678",
+			"device_id":    "42",
+			"cpu_count":    "2",
+			"memory_gb":    "4",
+			"location":     "SYD1",
+			"create_phase": phase,
+		},
+	}
+}
+
+func TestResourceRackcorpServerReadPowerState(t *testing.T) {
+	testCases := []struct {
+		phase          string
+		wantPowerState string
+	}{
+		{createPhaseDeviceAssigned, ""},
+		{createPhaseStartupSent, ""},
+		{createPhaseComplete, powerStateOff},
+		{"", powerStateOff},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.phase, func(t *testing.T) {
+			client := newTestServerClient("OFFLINE")
+			d := resourceRackcorpServer().Data(newTestServerState(tc.phase))
+
+			err := resourceRackcorpServerRead(d, providerConfig{Client: client})
+			if err != nil {
+				t.Fatalf("resourceRackcorpServerRead() failed: %s", err)
+			}
+
+			if got := d.Get("power_state").(string); got != tc.wantPowerState {
+				t.Errorf("power_state = %q, want %q", got, tc.wantPowerState)
+			}
+		})
+	}
+}
+
+func TestResourceRackcorpServerResumeCreateWithoutPowerState(t *testing.T) {
+	defer skipWaitDelay()()
+
+	// The device is still off when the state is refreshed before the apply
+	// that resumes the create.
+	client := newTestServerClient("OFFLINE")
+	config := providerConfig{Client: client}
+	d := resourceRackcorpServer().Data(newTestServerState(createPhaseStartupSent))
+
+	err := resourceRackcorpServerRead(d, config)
+	if err != nil {
+		t.Fatalf("resourceRackcorpServerRead() failed: %s", err)
+	}
+
+	client.addDevice(42, "ONLINE")
+	err = resourceRackcorpServerResumeCreate(
+		d, config, createPhaseStartupSent, d.Get("power_state").(string), time.Now().Add(10*time.Second))
+	if err != nil {
+		t.Fatalf("resourceRackcorpServerResumeCreate() failed: %s", err)
+	}
+
+	if types := client.transactionTypes("42"); len(types) != 0 {
+		t.Errorf("resumed create sent transactions %v, want none", types)
+	}
+	if got := d.Get("create_phase").(string); got != createPhaseComplete {
+		t.Errorf("create_phase = %q, want %q", got, createPhaseComplete)
+	}
+}
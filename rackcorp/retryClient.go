@@ -0,0 +1,175 @@
+package rackcorp
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+const retryBaseBackoff = time.Second
+
+// retryClient retries failed Rackcorp API requests with jittered exponential
+// backoff. Reads are retried on any transient failure. Requests that change
+// something are only retried when Rackcorp cannot have acted on them.
+type retryClient struct {
+	client     api.Client
+	maxRetries int
+	maxBackoff time.Duration
+}
+
+func newRetryClient(client api.Client, maxRetries int, maxBackoff time.Duration) api.Client {
+	return &retryClient{
+		client:     client,
+		maxRetries: maxRetries,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// isTransientError reports whether a failed read is worth retrying. Rackcorp
+// sends the debug text rather than the FAULT code when it has some, so any API
+// error that is not known to be permanent is retried.
+func isTransientError(err error) bool {
+	err = classifyAPIError(err)
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) {
+		return !errors.Is(err, errAPINotFound) &&
+			!errors.Is(err, errAPIAccessDenied) &&
+			!errors.Is(err, errAPIValidation)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// Proxies and load balancers answer with HTML when the API is unavailable.
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr)
+}
+
+// isUnsentRequestError reports whether a failed request was not acted on by
+// Rackcorp, so that it can be retried without repeating a change.
+func isUnsentRequestError(err error) bool {
+	if errors.Is(classifyAPIError(err), errAPIRateLimited) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func (c *retryClient) backoff(attempt int) time.Duration {
+	backoff := c.maxBackoff
+	if attempt < 32 && retryBaseBackoff<<uint(attempt) < c.maxBackoff {
+		backoff = retryBaseBackoff << uint(attempt)
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+func (c *retryClient) retry(operation string, retryable func(error) bool, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= c.maxRetries || !retryable(err) {
+			return err
+		}
+
+		// Only log the cause, the api package formats the whole request into
+		// transport errors and that includes the API secret.
+		backoff := c.backoff(attempt)
+		log.Printf("[WARN] Rackcorp %s request failed, retrying in %s: %s", operation, backoff, errors.Cause(err))
+		time.Sleep(backoff)
+	}
+}
+
+func (c *retryClient) OrderConfirm(orderID string) (*api.ConfirmedOrder, error) {
+	var result *api.ConfirmedOrder
+	err := c.retry("OrderConfirm", isUnsentRequestError, func() (err error) {
+		result, err = c.client.OrderConfirm(orderID)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) OrderCreate(productCode string, customerID string, productDetails api.ProductDetails) (*api.CreatedOrder, error) {
+	var result *api.CreatedOrder
+	err := c.retry("OrderCreate", isUnsentRequestError, func() (err error) {
+		result, err = c.client.OrderCreate(productCode, customerID, productDetails)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) OrderGet(orderID string) (*api.Order, error) {
+	var result *api.Order
+	err := c.retry("OrderGet", isTransientError, func() (err error) {
+		result, err = c.client.OrderGet(orderID)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) OrderContractGet(contractID string) (*api.OrderContract, error) {
+	var result *api.OrderContract
+	err := c.retry("OrderContractGet", isTransientError, func() (err error) {
+		result, err = c.client.OrderContractGet(contractID)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) DeviceGet(deviceID int) (*api.Device, error) {
+	var result *api.Device
+	err := c.retry("DeviceGet", isTransientError, func() (err error) {
+		result, err = c.client.DeviceGet(deviceID)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) DeviceUpdateFirewall(deviceID int, policies []api.FirewallPolicy) error {
+	return c.retry("DeviceUpdateFirewall", isUnsentRequestError, func() error {
+		return c.client.DeviceUpdateFirewall(deviceID, policies)
+	})
+}
+
+func (c *retryClient) TransactionCreate(transactionType string, objectType string, objectID string, confirm bool) (*api.Transaction, error) {
+	var result *api.Transaction
+	err := c.retry("TransactionCreate", isUnsentRequestError, func() (err error) {
+		result, err = c.client.TransactionCreate(transactionType, objectType, objectID, confirm)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) TransactionDeviceStartup(deviceID string, data api.TransactionStartupData) (*api.Transaction, error) {
+	var result *api.Transaction
+	err := c.retry("TransactionDeviceStartup", isUnsentRequestError, func() (err error) {
+		result, err = c.client.TransactionDeviceStartup(deviceID, data)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) TransactionGet(transactionID string) (*api.Transaction, error) {
+	var result *api.Transaction
+	err := c.retry("TransactionGet", isTransientError, func() (err error) {
+		result, err = c.client.TransactionGet(transactionID)
+		return err
+	})
+	return result, err
+}
+
+func (c *retryClient) TransactionGetAll(filter api.TransactionFilter) ([]api.Transaction, int, error) {
+	var result []api.Transaction
+	var matches int
+	err := c.retry("TransactionGetAll", isTransientError, func() (err error) {
+		result, matches, err = c.client.TransactionGetAll(filter)
+		return err
+	})
+	return result, matches, err
+}
@@ -0,0 +1,38 @@
+package rackcorp
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/section-io/rackcorp-sdk-go/v2"
+)
+
+func TestIsTransientError(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"fault code", &api.ApiError{Message: "FAULT"}, true},
+		{"fault with debug text", &api.ApiError{Message: "database unavailable"}, true},
+		{"rate limited", &api.ApiError{Message: "Rate limit exceeded"}, true},
+		{"not found", &api.ApiError{Message: "Could not find device"}, false},
+		{"access denied", &api.ApiError{Message: "ACCESS_DENIED"}, false},
+		{"validation", &api.ApiError{Message: "Invalid deviceId"}, false},
+		{"network error", errors.Wrap(&net.OpError{Op: "read", Err: errors.New("connection reset")}, "HTTP POST failed"), true},
+		{"html response", errors.Wrap(syntaxErr, "Could not JSON decode response"), true},
+		{"argument error", errors.New("deviceId parameter is required."), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isTransientError(tc.err); got != tc.want {
+				t.Errorf("isTransientError(%q) = %t, want %t", tc.err, got, tc.want)
+			}
+		})
+	}
+}